@@ -2,12 +2,59 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"compress/gzip"
-	"os"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/MrSplidge/go-xmldom"
 )
 
+const (
+	alsExtension = ".als"
+	alcExtension = ".alc"
+	adgExtension = ".adg"
+	advExtension = ".adv"
+)
+
+// The schema version used by the Live releases whose projects can be examined.
+const supportedALSMajorVersion = "5"
+
+// The parser for Live sets, clips and presets, which are gzip-compressed XML with an <Ableton> root element.
+type alsParser struct{}
+
+var PF_ALS = registerProjectFormat(alsParser{})
+
+func (alsParser) Name() string { return "ALS" }
+
+func (alsParser) Extensions() []string {
+	return []string{alsExtension, alcExtension, adgExtension, advExtension}
+}
+
+func (alsParser) Sniff(header []byte, content io.ReaderAt, size int64) bool {
+	if !bytes.HasPrefix(header, []byte{0x1f, 0x8b}) {
+		return false
+	}
+	gzipReader, err := gzip.NewReader(io.NewSectionReader(content, 0, size))
+	if err != nil {
+		return false
+	}
+	defer gzipReader.Close()
+	decompressed := make([]byte, sniffLength)
+	n, _ := io.ReadFull(gzipReader, decompressed)
+	return bytes.Contains(decompressed[:n], []byte("<Ableton"))
+}
+
+func (alsParser) Examine(path string) *projectInformation {
+	return examineALS(path)
+}
+
 // Given a plugin DOM node, find the name of the track within which it appears.
 func findTrackNameForNode(node *xmldom.Node) string {
 	for {
@@ -25,14 +72,115 @@ func findTrackNameForNode(node *xmldom.Node) string {
 	return ""
 }
 
-// Examine the contents of a CPR file to obtain version information and a mapping of track names to plugin names.
+// Given a plugin DOM node, find the name of the innermost rack chain within which it appears.
+// Chains are Branch elements in sets and clips, and BranchPreset elements in device group presets.
+func findChainNameForNode(node *xmldom.Node) string {
+	for node = node.Parent; node != nil; node = node.Parent {
+		if strings.HasSuffix(node.Name, "Branch") || strings.HasSuffix(node.Name, "BranchPreset") {
+			if name := node.GetChild("Name"); name != nil {
+				if effectiveName := name.GetChild("EffectiveName"); effectiveName != nil {
+					return effectiveName.GetAttributeValue("Value")
+				}
+				return name.GetAttributeValue("Value")
+			}
+		}
+	}
+	return ""
+}
+
+// Check whether a file is a Live clip or preset, rather than a set, based on its extension.
+func isALSClipOrPreset(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case alcExtension, adgExtension, advExtension:
+		return true
+	}
+	return false
+}
+
+// Given a plugin name DOM node, find the name of the plugin's vendor from a sibling element, if there is one.
+func findVendorForNode(node *xmldom.Node) string {
+	if node.Parent != nil {
+		for _, name := range []string{"Manufacturer", "Vendor"} {
+			if vendor := node.Parent.GetChild(name); vendor != nil {
+				return vendor.GetAttributeValue("Value")
+			}
+		}
+	}
+	return ""
+}
+
+// Given a FileRef DOM node, find the absolute path of the file it refers to and its path relative to the project, either of which may be empty.
+// Live 11 records both paths in Value attributes. Earlier releases record the relative path as a list of RelativePathElement folders, and the name of the file separately.
+func findMediaPathsForFileRef(fileRef *xmldom.Node) (string, string) {
+	var recordedPath, relativePath string
+	if path := fileRef.GetChild("Path"); path != nil {
+		recordedPath = path.GetAttributeValue("Value")
+	}
+	if relative := fileRef.GetChild("RelativePath"); relative != nil {
+		relativePath = relative.GetAttributeValue("Value")
+		if elements := relative.GetChildren("RelativePathElement"); len(relativePath) == 0 && len(elements) != 0 {
+			folders := []string{}
+			for _, element := range elements {
+				// An empty folder name refers to the parent folder.
+				if folder := element.GetAttributeValue("Dir"); len(folder) != 0 {
+					folders = append(folders, folder)
+				} else {
+					folders = append(folders, "..")
+				}
+			}
+			if name := fileRef.GetChild("Name"); name != nil {
+				relativePath = strings.Join(append(folders, name.GetAttributeValue("Value")), "/")
+			}
+		}
+	}
+	return recordedPath, relativePath
+}
+
+// Splits Live's Creator attribute, such as "Ableton Live 11.3.13", into the name of the application and its release.
+var alsCreatorPattern = regexp.MustCompile(`^(.*?)\s+([0-9][^\s]*)$`)
+
+// Return the value of the first element that matches [queryPath] as a number, or zero if there is no such element or its value isn't a number.
+func queryALSNumber(root *xmldom.Node, queryPath string) float64 {
+	if node := root.QueryOne(queryPath); node != nil {
+		if value, err := strconv.ParseFloat(node.GetAttributeValue("Value"), 64); err == nil {
+			return value
+		}
+	}
+	return 0
+}
+
+// The mixer of the master track, which Live 12 calls the main track. Every track's mixer has tempo and time signature elements, but only the master track's are used.
+const alsMasterMixerQuery = "//*[self::MasterTrack or self::MainTrack]/DeviceChain/Mixer"
+
+// Extract the tempo, time signature and arrangement length of a Live set from the master track's mixer and the clips in the arrangement.
+func findALSMetadata(root *xmldom.Node, metadata *projectMetadata) {
+	metadata.tempo = queryALSNumber(root, alsMasterMixerQuery+"/Tempo/Manual")
+
+	// Live encodes a time signature as a single number: 99 times the power of two of the denominator, plus the numerator minus one, so that 4/4 is 201.
+	if node := root.QueryOne(alsMasterMixerQuery + "/TimeSignature/Manual"); node != nil {
+		if value, err := strconv.Atoi(node.GetAttributeValue("Value")); err == nil && value >= 0 && value/99 < 8 {
+			metadata.numerator, metadata.denominator = value%99+1, 1<<(value/99)
+		}
+	}
+
+	// Clip positions are measured in beats.
+	end := 0.0
+	for _, node := range root.Query("//ArrangerAutomation/Events/*/CurrentEnd") {
+		if value, err := strconv.ParseFloat(node.GetAttributeValue("Value"), 64); err == nil {
+			end = max(end, value)
+		}
+	}
+	metadata.length = beatsToDuration(end, metadata.tempo)
+}
+
+// Examine the contents of an ALS file, or of a Live clip (.alc), device group preset (.adg) or device preset (.adv), to obtain version information and a mapping of track names to plugin names.
 func examineALS(path string) *projectInformation {
 	info := newProjectInformation(path)
 
 	// Open the project file
-	file, err := os.Open(path)
+	file, _, err := openProjectFile(path)
 	if err != nil {
-		info.logError(err.Error())
+		info.logError(newProjectError(EK_IO, err))
 		return &info
 	}
 	defer file.Close()
@@ -40,7 +188,11 @@ func examineALS(path string) *projectInformation {
 	// Decompress the file
 	gzipReader, err := gzip.NewReader(bufio.NewReader(file))
 	if err != nil {
-		info.logError(err.Error())
+		if errors.Is(err, gzip.ErrHeader) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			info.logError(newProjectErrorAt(EK_NotGzip, "", 0, err))
+		} else {
+			info.logError(newProjectError(EK_IO, err))
+		}
 		return &info
 	}
 	defer gzipReader.Close()
@@ -48,7 +200,16 @@ func examineALS(path string) *projectInformation {
 	// Parse the decompressed file content into an XML DOM.
 	dom, err := xmldom.Parse(gzipReader)
 	if err != nil {
-		info.logError(err.Error())
+		var syntaxError *xml.SyntaxError
+		if errors.As(err, &syntaxError) {
+			info.logError(newProjectError(EK_MalformedXML, err))
+		} else {
+			info.logError(newProjectError(EK_IO, err))
+		}
+		return &info
+	}
+	if dom.Root == nil || dom.Root.Name != "Ableton" {
+		info.logError(newProjectError(EK_MalformedXML, fmt.Errorf("the root element is not <Ableton>")))
 		return &info
 	}
 
@@ -56,13 +217,39 @@ func examineALS(path string) *projectInformation {
 	if version := dom.Root.GetAttributeValue("MinorVersion"); len(version) != 0 {
 		info.version = version
 	}
+	majorVersion := dom.Root.GetAttributeValue("MajorVersion")
+	if creator := dom.Root.GetAttributeValue("Creator"); len(creator) != 0 {
+		info.metadata.creator = creator
+		if match := alsCreatorPattern.FindStringSubmatch(creator); match != nil {
+			info.application = match[1]
+		} else {
+			info.application = creator
+		}
+	}
+	findALSMetadata(dom.Root, &info.metadata)
+
+	// Clips and presets may not contain tracks, so the names of rack chains, or failing that the name of the file, stand in for track names.
+	trackNameForNode := findTrackNameForNode
+	if isALSClipOrPreset(path) {
+		presetName := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		trackNameForNode = func(node *xmldom.Node) string {
+			if track := findTrackNameForNode(node); len(track) != 0 {
+				return track
+			}
+			if chain := findChainNameForNode(node); len(chain) != 0 {
+				return chain
+			}
+			return presetName
+		}
+	}
 
 	// Extract a mapping of track names to plugins.
 	processPluginInfo := func(queryPath string) {
 		for _, node := range dom.Root.Query(queryPath) {
-			if track := findTrackNameForNode(node); len(track) != 0 {
+			if track := trackNameForNode(node); len(track) != 0 {
 				if plugin := node.GetAttributeValue("Value"); len(plugin) != 0 {
 					info.mapTrackToPlugin(plugin, track)
+					info.setPluginVendor(plugin, findVendorForNode(node))
 				}
 			}
 		}
@@ -72,5 +259,16 @@ func examineALS(path string) *projectInformation {
 	processPluginInfo("//VstPluginInfo/PlugName")
 	processPluginInfo("//Vst3PluginInfo/Name")
 
+	// Extract the media files referred to by samples.
+	for _, fileRef := range dom.Root.Query("//SampleRef/FileRef") {
+		recordedPath, relativePath := findMediaPathsForFileRef(fileRef)
+		info.addMediaReference(recordedPath, relativePath)
+	}
+
+	// Sets written by other versions of Live are often laid out the same way, so an unfamiliar version is only a warning.
+	if majorVersion != supportedALSMajorVersion {
+		info.logWarning(fmt.Sprintf("MajorVersion %q is not %q, so the set may not have been read fully", majorVersion, supportedALSMajorVersion))
+	}
+
 	return &info
 }
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const testALSSet = `<?xml version="1.0" encoding="UTF-8"?>
+<Ableton MajorVersion="5" MinorVersion="11.0_11300" Creator="Ableton Live 11.3.13">
+	<LiveSet>
+		<Tracks>
+			<MidiTrack Id="1">
+				<Name><EffectiveName Value="5 D3.RBass1"/></Name>
+				<DeviceChain><DeviceChain><Devices>
+					<PluginDevice Id="0"><PluginDesc><Vst3PluginInfo Id="0"><Name Value="DUNE 3"/></Vst3PluginInfo></PluginDesc></PluginDevice>
+					<AudioEffectGroupDevice Id="1"><Branches><AudioEffectBranch Id="0">
+						<Name><EffectiveName Value="Clip Chain"/></Name>
+						<DeviceChain><AudioToAudioDeviceChain><Devices>
+							<PluginDevice Id="0"><PluginDesc><VstPluginInfo Id="0"><PlugName Value="StandardCLIP"/></VstPluginInfo></PluginDesc></PluginDevice>
+						</Devices></AudioToAudioDeviceChain></DeviceChain>
+					</AudioEffectBranch></Branches></AudioEffectGroupDevice>
+				</Devices></DeviceChain></DeviceChain>
+			</MidiTrack>
+		</Tracks>
+		<MasterTrack>
+			<Name><EffectiveName Value="Master"/></Name>
+			<DeviceChain><DeviceChain><Devices>
+				<PluginDevice Id="0"><PluginDesc><VstPluginInfo Id="0"><PlugName Value="DSEQ3"/></VstPluginInfo></PluginDesc></PluginDevice>
+			</Devices></DeviceChain></DeviceChain>
+		</MasterTrack>
+	</LiveSet>
+</Ableton>`
+
+const testADGPreset = `<?xml version="1.0" encoding="UTF-8"?>
+<Ableton MajorVersion="5" MinorVersion="11.0_433">
+	<GroupDevicePreset>
+		<Device><InstrumentGroupDevice Id="0"/></Device>
+		<BranchPresets>
+			<InstrumentBranchPreset Id="0">
+				<Name Value="Avenger Layer"/>
+				<DevicePresets>
+					<PluginDevice Id="0"><PluginDesc><VstPluginInfo Id="0"><PlugName Value="VPS Avenger"/></VstPluginInfo></PluginDesc></PluginDevice>
+				</DevicePresets>
+			</InstrumentBranchPreset>
+			<InstrumentBranchPreset Id="1">
+				<Name Value="Dune Layer"/>
+				<DevicePresets>
+					<PluginDevice Id="0"><PluginDesc><Vst3PluginInfo Id="0"><Name Value="DUNE 3"/></Vst3PluginInfo></PluginDesc></PluginDevice>
+				</DevicePresets>
+			</InstrumentBranchPreset>
+		</BranchPresets>
+	</GroupDevicePreset>
+</Ableton>`
+
+const testADVPreset = `<?xml version="1.0" encoding="UTF-8"?>
+<Ableton MajorVersion="5" MinorVersion="11.0_433">
+	<PluginDevice Id="0"><PluginDesc><VstPluginInfo Id="0"><PlugName Value="Kick 2 x64"/></VstPluginInfo></PluginDesc></PluginDevice>
+</Ableton>`
+
+func TestExamineALS(t *testing.T) {
+	folder := t.TempDir()
+
+	tests := []struct {
+		name     string
+		xml      string
+		expected map[string][]string
+	}{
+		{"43.als", testALSSet, map[string][]string{"5 D3.RBass1": {"DUNE 3", "StandardCLIP"}, "Master": {"DSEQ3"}}},
+		{"Layers.adg", testADGPreset, map[string][]string{"Avenger Layer": {"VPS Avenger"}, "Dune Layer": {"DUNE 3"}}},
+		{"Big Kick.adv", testADVPreset, map[string][]string{"Big Kick": {"Kick 2 x64"}}},
+	}
+	for _, test := range tests {
+		path := writeTestFile(t, filepath.Join(folder, test.name), gzippedXML(t, test.xml))
+		pi := examineALS(path)
+		if pi.failed() {
+			t.Errorf("%s: unexpected errors %v", test.name, pi.errors)
+			continue
+		}
+		for _, plugins := range pi.trackToPluginMap {
+			slices.Sort(plugins)
+		}
+		if !reflect.DeepEqual(pi.trackToPluginMap, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, pi.trackToPluginMap)
+		}
+	}
+}
+
+func TestExamineALSUnfamiliarVersion(t *testing.T) {
+	folder := t.TempDir()
+
+	// Sets from other versions of Live are examined as usual, with a warning, even if they are empty.
+	tests := []struct {
+		name     string
+		xml      string
+		expected map[string][]string
+	}{
+		{"Old.als", strings.Replace(testALSSet, `MajorVersion="5"`, `MajorVersion="4"`, 1), map[string][]string{"5 D3.RBass1": {"DUNE 3", "StandardCLIP"}, "Master": {"DSEQ3"}}},
+		{"Empty.als", `<Ableton MajorVersion="6" MinorVersion="12.0_12049"><LiveSet/></Ableton>`, map[string][]string{}},
+	}
+	for _, test := range tests {
+		pi := examineALS(writeTestFile(t, filepath.Join(folder, test.name), gzippedXML(t, test.xml)))
+		if pi.failed() || len(pi.warnings) != 1 {
+			t.Errorf("%s: expected one warning and no errors, got %v and %v", test.name, pi.warnings, pi.errors)
+			continue
+		}
+		for _, plugins := range pi.trackToPluginMap {
+			slices.Sort(plugins)
+		}
+		if !reflect.DeepEqual(pi.trackToPluginMap, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, pi.trackToPluginMap)
+		}
+		if output := pi.ColouredString("", "", "", "", ""); !strings.Contains(output, "Warnings:\n "+pi.warnings[0]) {
+			t.Errorf("%s: expected the warning to be listed, got %q", test.name, output)
+		}
+	}
+}
+
+func TestExamineALSErrors(t *testing.T) {
+	folder := t.TempDir()
+
+	tests := []struct {
+		name    string
+		content []byte
+		kind    errorKind
+	}{
+		{"NotGzip.als", []byte("plain text"), EK_NotGzip},
+		{"Malformed.als", gzippedXML(t, "<Ableton><LiveSet>"), EK_MalformedXML},
+		{"NotAbleton.als", gzippedXML(t, "<Bitwig/>"), EK_MalformedXML},
+	}
+	for _, test := range tests {
+		pi := examineALS(writeTestFile(t, filepath.Join(folder, test.name), test.content))
+		if len(pi.errors) != 1 || pi.errors[0].kind != test.kind {
+			t.Errorf("%s: expected a %s error, got %v", test.name, test.kind, pi.errors)
+		}
+	}
+}
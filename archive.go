@@ -0,0 +1,364 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	zipExtension = ".zip"
+	alpExtension = ".alp"
+)
+
+// The extensions of archives within which project files are looked for. Ableton Packs are read as zip archives.
+var archiveExtensions = []string{zipExtension, alpExtension}
+
+// Separates the path of an archive from the path of an entry within it, as in "Archive.zip!/Set/Set.als". Entries within nested archives have more than one separator.
+const archiveSeparator = "!/"
+
+// Check whether a file is an archive within which project files are looked for, based on its extension.
+func isArchive(path string) bool {
+	return slices.Contains(archiveExtensions, strings.ToLower(filepath.Ext(path)))
+}
+
+// Split a path that names an entry within an archive into the path of the archive and the name of the entry. The last separator that follows the name of an archive is used, so the archive may itself be an entry within another archive,
+// and folders whose names end with an exclamation mark aren't mistaken for archives. Returns false if the path doesn't name an entry within an archive.
+func splitArchivePath(path string) (string, string, bool) {
+	for index := strings.LastIndex(path, archiveSeparator); index >= 0; index = strings.LastIndex(path[:index], archiveSeparator) {
+		if isArchive(path[:index]) {
+			return path[:index], path[index+len(archiveSeparator):], true
+		}
+	}
+	return path, "", false
+}
+
+// Return the path of the file on disk that holds a project file. For entries within archives, including nested archives, this is the outermost archive.
+func outermostFile(path string) string {
+	for {
+		archivePath, _, isEntry := splitArchivePath(path)
+		if !isEntry {
+			return path
+		}
+		path = archivePath
+	}
+}
+
+// A project file opened for reading, which may be a file on disk or an entry within an archive.
+type projectReader interface {
+	io.ReadSeekCloser
+	io.ReaderAt
+}
+
+// An entry within an archive, whose content has been read into memory.
+type archiveEntryReader struct {
+	*bytes.Reader
+}
+
+func (archiveEntryReader) Close() error { return nil }
+
+// The number of opened archives that are kept for reuse. Scans visit the entries of each archive together, so a few are enough to avoid opening an archive, and reading a nested archive into memory,
+// for every entry that is sized, sniffed or examined.
+const archiveCacheSize = 8
+
+// An opened archive, kept for reuse while the file on disk that holds it is unchanged.
+type cachedArchive struct {
+	path    string
+	modTime time.Time // The modification time and size of the outermost file when the archive was opened.
+	size    int64
+	archive *zip.Reader
+	closer  io.Closer
+	users   int  // The number of callers that haven't yet released the archive.
+	evicted bool // Whether the archive has been removed from the cache, and should be closed once it is released.
+}
+
+// Release a cached archive, closing it if it has been evicted and has no other users.
+func (ca *cachedArchive) Close() error {
+	openArchives.mutex.Lock()
+	defer openArchives.mutex.Unlock()
+	ca.users--
+	if ca.evicted && ca.users == 0 {
+		return ca.closer.Close()
+	}
+	return nil
+}
+
+// The archives opened most recently, least recently used first.
+var openArchives struct {
+	mutex    sync.Mutex
+	archives []*cachedArchive
+}
+
+// Open an archive, which may itself be an entry within another archive. The returned closer must be called when the archive is no longer needed.
+// Archives are kept open for reuse by later calls, until they are evicted by others or the file on disk that holds them changes.
+func openArchive(archivePath string) (*zip.Reader, io.Closer, error) {
+	fileInfo, err := os.Stat(outermostFile(archivePath))
+	if err != nil {
+		return nil, nil, err
+	}
+	if ca := findCachedArchive(archivePath, fileInfo); ca != nil {
+		return ca.archive, ca, nil
+	}
+
+	// The archive is opened without holding the lock, because opening a nested archive opens the archive that contains it.
+	reader, size, err := openProjectFile(archivePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	archive, err := zip.NewReader(reader, size)
+	if err != nil {
+		reader.Close()
+		return nil, nil, err
+	}
+	return addCachedArchive(&cachedArchive{path: archivePath, modTime: fileInfo.ModTime(), size: fileInfo.Size(), archive: archive, closer: reader})
+}
+
+// Find an archive in the cache that was opened from the file described by [fileInfo], and mark it as used. Returns nil if there is no such archive.
+func findCachedArchive(archivePath string, fileInfo os.FileInfo) *cachedArchive {
+	openArchives.mutex.Lock()
+	defer openArchives.mutex.Unlock()
+	for index, ca := range openArchives.archives {
+		if ca.path == archivePath && ca.modTime.Equal(fileInfo.ModTime()) && ca.size == fileInfo.Size() {
+			openArchives.archives = append(slices.Delete(openArchives.archives, index, index+1), ca)
+			ca.users++
+			return ca
+		}
+	}
+	return nil
+}
+
+// Add a newly opened archive to the cache, marked as used, replacing any older copy of it and evicting the least recently used archives if the cache is full.
+func addCachedArchive(added *cachedArchive) (*zip.Reader, io.Closer, error) {
+	openArchives.mutex.Lock()
+	defer openArchives.mutex.Unlock()
+	added.users = 1
+	archives := []*cachedArchive{}
+	for _, ca := range openArchives.archives {
+		if ca.path == added.path {
+			ca.evict()
+		} else {
+			archives = append(archives, ca)
+		}
+	}
+	archives = append(archives, added)
+	for len(archives) > archiveCacheSize {
+		archives[0].evict()
+		archives = archives[1:]
+	}
+	openArchives.archives = archives
+	return added.archive, added, nil
+}
+
+// Remove every archive from the cache, closing the ones that aren't in use. Commands call this once they have finished with the files found by a scan, so that archives aren't held open afterwards.
+func closeArchives() {
+	openArchives.mutex.Lock()
+	defer openArchives.mutex.Unlock()
+	for _, ca := range openArchives.archives {
+		ca.evict()
+	}
+	openArchives.archives = nil
+}
+
+// Remove an archive from the cache, closing it straight away if nothing is using it. The caller must hold the lock.
+func (ca *cachedArchive) evict() {
+	ca.evicted = true
+	if ca.users == 0 {
+		ca.closer.Close()
+	}
+}
+
+// Find an entry within an archive. Returns an error that wraps fs.ErrNotExist if there is no such entry.
+func findArchiveEntry(archive *zip.Reader, archivePath, entryName string) (*zip.File, error) {
+	for _, file := range archive.File {
+		if file.Name == entryName {
+			return file, nil
+		}
+	}
+	return nil, fmt.Errorf("%s%s%s: %w", archivePath, archiveSeparator, entryName, fs.ErrNotExist)
+}
+
+// Open a project file for reading, and return it with its size. Paths that name an entry within an archive, such as "Archive.zip!/Set/Set.als", are resolved by reading the entry into memory.
+func openProjectFile(path string) (projectReader, int64, error) {
+	archivePath, entryName, isEntry := splitArchivePath(path)
+	if !isEntry {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, 0, err
+		}
+		fileInfo, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return nil, 0, err
+		}
+		return file, fileInfo.Size(), nil
+	}
+
+	archive, closer, err := openArchive(archivePath)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer closer.Close()
+
+	entry, err := findArchiveEntry(archive, archivePath, entryName)
+	if err != nil {
+		return nil, 0, err
+	}
+	entryReader, err := entry.Open()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer entryReader.Close()
+	content, err := io.ReadAll(entryReader)
+	if err != nil {
+		return nil, 0, err
+	}
+	return archiveEntryReader{bytes.NewReader(content)}, int64(len(content)), nil
+}
+
+// Return the folder that contains a project file, which may be a folder within an archive. The folder at the top of an archive is named with a trailing separator, as in "Archive.zip!/".
+func projectFileDir(p string) string {
+	archivePath, entryName, isEntry := splitArchivePath(p)
+	if !isEntry {
+		return filepath.Dir(p)
+	}
+	if dir := path.Dir(entryName); dir != "." {
+		return archivePath + archiveSeparator + dir
+	}
+	return archivePath + archiveSeparator
+}
+
+// Return the names of the files directly within a folder, which may be a folder within an archive as returned by [projectFileDir]. Folders aren't included.
+func listProjectFolder(dir string) ([]string, error) {
+	names := []string{}
+	archivePath, folder, isEntry := splitArchivePath(dir)
+	if !isEntry {
+		entries, err := os.ReadDir(dir)
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				names = append(names, entry.Name())
+			}
+		}
+		return names, err
+	}
+
+	archive, closer, err := openArchive(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+
+	prefix := ""
+	if len(folder) != 0 {
+		prefix = strings.TrimSuffix(folder, "/") + "/"
+	}
+	for _, file := range archive.File {
+		if name, found := strings.CutPrefix(file.Name, prefix); found && len(name) != 0 && !strings.Contains(name, "/") && !file.FileInfo().IsDir() {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// Join a folder returned by [projectFileDir] and the name of a file within it.
+func joinProjectFilePath(dir, name string) string {
+	if _, entryName, isEntry := splitArchivePath(dir); isEntry {
+		if len(entryName) == 0 {
+			return dir + name
+		}
+		return dir + "/" + name
+	}
+	return filepath.Join(dir, name)
+}
+
+// Read the whole of a project file, which may be an entry within an archive.
+func readProjectFile(path string) ([]byte, error) {
+	reader, _, err := openProjectFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// Return the size of a project file, which may be an entry within an archive, without reading it.
+func projectFileSize(path string) (int64, error) {
+	archivePath, entryName, isEntry := splitArchivePath(path)
+	if !isEntry {
+		fileInfo, err := os.Stat(path)
+		if err != nil {
+			return 0, err
+		}
+		if fileInfo.IsDir() {
+			return 0, fmt.Errorf("%s is a folder", path)
+		}
+		return fileInfo.Size(), nil
+	}
+
+	archive, closer, err := openArchive(archivePath)
+	if err != nil {
+		return 0, err
+	}
+	defer closer.Close()
+
+	entry, err := findArchiveEntry(archive, archivePath, entryName)
+	if err != nil {
+		return 0, err
+	}
+	return int64(entry.UncompressedSize64), nil
+}
+
+// List the files within an archive, and within any archives nested inside it, that pass the filters, as paths of the form "Archive.zip!/Set/Set.als".
+// The folder filter is applied to each folder within an entry's path, so that ignored folders are skipped within archives too.
+func listArchive(archivePath string, includeFolder func(basename, fullPath string) bool, includeFile func(basename, fullPath string) bool) ([]string, error) {
+	archive, closer, err := openArchive(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+
+	paths := []string{}
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+
+		fullPath := archivePath + archiveSeparator + file.Name
+		folders := strings.Split(path.Dir(file.Name), "/")
+		included := true
+		for index, folder := range folders {
+			if folder != "." && !includeFolder(folder, archivePath+archiveSeparator+strings.Join(folders[:index+1], "/")) {
+				included = false
+				break
+			}
+		}
+		if !included {
+			continue
+		}
+
+		basename := path.Base(file.Name)
+		if isArchive(basename) {
+			paths = append(paths, listArchiveOrWarn(fullPath, includeFolder, includeFile)...)
+		} else if includeFile(basename, fullPath) {
+			paths = append(paths, fullPath)
+		}
+	}
+	return paths, nil
+}
+
+// List the files within an archive that pass the filters, printing a warning to stderr if the archive can't be read.
+func listArchiveOrWarn(archivePath string, includeFolder func(basename, fullPath string) bool, includeFile func(basename, fullPath string) bool) []string {
+	paths, err := listArchive(archivePath, includeFolder, includeFile)
+	if err != nil {
+		os.Stderr.WriteString(fmt.Errorf("can't open archive %s: %w", archivePath, err).Error() + "\n")
+	}
+	return paths
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestSplitArchivePath(t *testing.T) {
+	tests := []struct {
+		path    string
+		archive string
+		entry   string
+		isEntry bool
+	}{
+		{"Music/Archive.zip!/Set/Set.als", "Music/Archive.zip", "Set/Set.als", true},
+		{"Archive.zip!/Inner.ZIP!/Project.cpr", "Archive.zip!/Inner.ZIP", "Project.cpr", true},
+		{"Pack.alp!/Sets/Demo.als", "Pack.alp", "Sets/Demo.als", true},
+		{"Yes!/Set.als", "Yes!/Set.als", "", false},
+		{"Archive.zip!/Wow!/Set.als", "Archive.zip", "Wow!/Set.als", true},
+		{"Set.als", "Set.als", "", false},
+	}
+	for _, test := range tests {
+		archive, entry, isEntry := splitArchivePath(test.path)
+		if archive != test.archive || entry != test.entry || isEntry != test.isEntry {
+			t.Errorf("%s: expected %q %q %v, got %q %q %v", test.path, test.archive, test.entry, test.isEntry, archive, entry, isEntry)
+		}
+	}
+}
+
+func TestScanArchives(t *testing.T) {
+	folder := t.TempDir()
+	t.Cleanup(closeArchives)
+	als := string(gzippedXML(t, testALSSet))
+	cpr := string(syntheticCPR("Cubase", "Version 13.0.21"))
+	inner := string(zipArchive(t, "Song/Song.cpr", cpr, "Song/Song-01.bak", cpr))
+	archive := writeTestFile(t, filepath.Join(folder, "Finished.zip"), zipArchive(t,
+		"Set/Set.als", als,
+		"Set/Backup/Set [2024-03-01 101500].als", als,
+		"Set/readme.txt", "hello",
+		"Cubase.zip", inner))
+	writeTestFile(t, filepath.Join(folder, "Damaged.zip"), []byte("not a zip archive"))
+
+	so := scanOptions{foldersToIgnore: stringFlags{"Backup"}}
+	paths := so.find([]string{folder})
+	slices.Sort(paths)
+	expected := []string{
+		archive + "!/Cubase.zip!/Song/Song-01.bak",
+		archive + "!/Cubase.zip!/Song/Song.cpr",
+		archive + "!/Set/Set.als",
+	}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Fatalf("Expected %q, got %q", expected, paths)
+	}
+
+	pi := examineProject(archive + "!/Set/Set.als")
+	if pi == nil || pi.failed() || pi.format != PF_ALS || len(pi.trackToPluginMap) == 0 {
+		t.Errorf("Expected the set within the archive to be examined, got %+v", pi)
+	}
+
+	backup := examineProject(archive + "!/Cubase.zip!/Song/Song-01.bak")
+	if backup == nil || backup.failed() || backup.backupOf != archive+"!/Cubase.zip!/Song/Song.cpr" {
+		t.Errorf("Expected the backup within the nested archive to name its project, got %+v", backup)
+	}
+
+	if size, err := projectFileSize(archive + "!/Set/readme.txt"); err != nil || size != 5 {
+		t.Errorf("Expected the size of the entry to be 5, got %d %v", size, err)
+	}
+	if _, err := projectFileSize(archive + "!/Set/Missing.als"); err == nil {
+		t.Error("Expected an error for a missing entry")
+	}
+
+	// Entries within archives can be named directly.
+	if paths := so.find([]string{archive + "!/Set/Set.als"}); len(paths) != 1 {
+		t.Errorf("Expected the entry to be found, got %q", paths)
+	}
+}
+
+func TestArchiveCache(t *testing.T) {
+	folder := t.TempDir()
+	t.Cleanup(closeArchives)
+	inner := string(zipArchive(t, "Set/Set.als", "set"))
+	archive := writeTestFile(t, filepath.Join(folder, "Sets.zip"), zipArchive(t, "Inner.zip", inner))
+	nested := archive + "!/Inner.zip"
+
+	// Opening an archive again, or opening the archive within it again, reuses the archives that are already open.
+	first, firstCloser, err := openArchive(nested)
+	if err != nil {
+		t.Fatal(err)
+	}
+	firstCloser.Close()
+	second, secondCloser, err := openArchive(nested)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secondCloser.Close()
+	if first != second {
+		t.Errorf("Expected the nested archive to be reused")
+	}
+
+	// Changing the file on disk means that the archives within it are opened again.
+	writeTestFile(t, archive, zipArchive(t, "Inner.zip", inner, "Other.txt", "other"))
+	third, thirdCloser, err := openArchive(nested)
+	if err != nil {
+		t.Fatal(err)
+	}
+	thirdCloser.Close()
+	if third == first {
+		t.Errorf("Expected the nested archive to be opened again after the archive changed")
+	}
+
+	// Archives are closed once they have been removed from the cache, so that they can be deleted.
+	closeArchives()
+	if err := os.Remove(archive); err != nil {
+		t.Errorf("Expected the archive to be closed: %v", err)
+	}
+}
+
+func TestListProjectFolder(t *testing.T) {
+	folder := t.TempDir()
+	t.Cleanup(closeArchives)
+	archive := writeTestFile(t, filepath.Join(folder, "Sets.zip"), zipArchive(t,
+		"Set/Set.als", "set", "Set/Backup/Set [2024-03-01 101500].als", "backup", "Top.als", "top"))
+	writeTestFile(t, filepath.Join(folder, "Loose.als"), []byte("loose"))
+
+	tests := []struct {
+		dir      string
+		expected []string
+	}{
+		{folder, []string{"Loose.als", "Sets.zip"}},
+		{archive + archiveSeparator, []string{"Top.als"}},
+		{archive + "!/Set", []string{"Set.als"}},
+		{archive + "!/Set/Backup", []string{"Set [2024-03-01 101500].als"}},
+	}
+	for _, test := range tests {
+		names, err := listProjectFolder(test.dir)
+		slices.Sort(names)
+		if err != nil || !reflect.DeepEqual(names, test.expected) {
+			t.Errorf("%s: expected %q, got %q %v", test.dir, test.expected, names, err)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testArdourSession = `<?xml version="1.0" encoding="UTF-8"?>
+<Session version="7003" name="Film" sample-rate="48000">
+  <ProgramVersion created-with="Ardour 8.1.0" modified-with="Ardour 8.4.0"/>
+  <Routes>
+    <Route version="7003" id="1" name="Master" default-type="audio">
+      <Processor id="2" name="Amp" active="1" type="amp"/>
+      <Processor id="3" name="ACE Compressor" active="1" type="lv2" unique-id="urn:ardour:a-comp"/>
+    </Route>
+    <Route version="7003" id="4" name="Dialogue" default-type="audio">
+      <Processor id="5" name="Trim" active="1" type="trim"/>
+      <Processor id="6" name="TAP Equalizer" active="1" type="ladspa" unique-id="2141"/>
+      <Processor id="7" name="Pro-Q 3" active="1" type="vst3" unique-id="ABCD"/>
+      <Processor id="8" name="a-Delay" active="1" type="luaproc"/>
+      <Processor id="9" name="Surge XT" active="1" type="lxvst"/>
+      <Processor id="10" name="Reverb Bus" active="1" type="intsend"/>
+    </Route>
+  </Routes>
+</Session>`
+
+func TestExamineArdour(t *testing.T) {
+	path := writeTestFile(t, filepath.Join(t.TempDir(), "Film.ardour"), []byte(testArdourSession))
+
+	if format := chooseProjectFormat(path); format != PF_Ardour {
+		t.Fatalf("Expected an Ardour session, got %s", format)
+	}
+
+	pi := examineProject(path)
+	if pi.failed() {
+		t.Fatalf("Unexpected errors: %v", pi.errors)
+	}
+	if pi.application != "Ardour" || pi.version != "8.4.0" {
+		t.Errorf("Expected Ardour 8.4.0, got %q %q", pi.application, pi.version)
+	}
+	expected := map[string][]string{
+		"Master":   {"ACE Compressor"},
+		"Dialogue": {"TAP Equalizer", "Pro-Q 3", "a-Delay", "Surge XT"},
+	}
+	if !reflect.DeepEqual(pi.trackToPluginMap, expected) {
+		t.Errorf("Expected %v, got %v", expected, pi.trackToPluginMap)
+	}
+
+	pi = examineArdour(writeTestFile(t, filepath.Join(t.TempDir(), "Old.ardour"), []byte(`<?xml version="1.0"?><Session version="3001"><Routes/></Session>`)))
+	if pi.failed() || pi.version != "3001" {
+		t.Errorf("Expected the session format version, got %q %v", pi.version, pi.errors)
+	}
+
+	pi = examineArdour(writeTestFile(t, filepath.Join(t.TempDir(), "Other.ardour"), []byte(`<?xml version="1.0"?><svg/>`)))
+	if len(pi.errors) != 1 || pi.errors[0].kind != EK_MalformedXML {
+		t.Errorf("Expected a malformed XML error, got %v", pi.errors)
+	}
+}
@@ -1,10 +1,57 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
-	"os"
+	"io"
+	"math"
+	"path/filepath"
+	"strings"
 )
 
+const (
+	cprExtension         = ".cpr"
+	nprExtension         = ".npr"
+	bakExtension         = ".bak"
+	trackPresetExtension = ".trackpreset"
+)
+
+// The parser for Cubase projects, backups and track presets, which are RIFF files with a NUND form type whose first chunk is a ROOT chunk.
+type cprParser struct{}
+
+var PF_CPR = registerProjectFormat(cprParser{})
+
+func (cprParser) Name() string { return "CPR" }
+
+func (cprParser) Extensions() []string {
+	return []string{cprExtension, bakExtension, trackPresetExtension}
+}
+
+func (cprParser) Sniff(header []byte, content io.ReaderAt, size int64) bool {
+	return len(header) >= 16 && bytes.Equal(header[0:4], []byte("RIFF")) && bytes.Equal(header[8:12], []byte("NUND")) && bytes.Equal(header[12:16], []byte("ROOT"))
+}
+
+// Examine a project in Cubase's format, and tell Nuendo projects apart from Cubase projects by the application that wrote them.
+func (cprParser) Examine(path string) *projectInformation {
+	pi := examineCPR(path)
+	pi.backupOf = findCubaseBackupParent(path)
+	pi.format = cubaseProjectFormat(pi)
+	return pi
+}
+
+// The parser for Nuendo projects. They share Cubase's format, so they are detected by the Cubase parser, and this parser only claims their extension.
+type nprParser struct {
+	cprParser
+}
+
+var PF_NPR = registerProjectFormat(nprParser{})
+
+func (nprParser) Name() string { return "NPR" }
+
+func (nprParser) Extensions() []string { return []string{nprExtension} }
+
+func (nprParser) Sniff(header []byte, content io.ReaderAt, size int64) bool { return false }
+
 // Represents a position within a slice. This could almost be a simple slice, but the position enables spans to be ordered.
 type span struct {
 	position int
@@ -14,44 +61,55 @@ type span struct {
 // Advance a span by [count] bytes. The span cannot be advanced beyond the end of its slice, at which point the [empty] method will return true.
 func (s span) advance(count int) span {
 	return span{
-		position: min(s.position+count, len(s.bytes)),
+		position: max(s.position, min(s.position+count, len(s.bytes))),
 		bytes:    s.bytes,
 	}
 }
 
-// Return the byte at the specified offset from the span's current position.
+// Return the byte at the specified offset from the span's current position. The caller must check [hasBytes] first.
 func (s span) at(offset int) byte {
 	return s.bytes[s.position+offset]
 }
 
-// Return a span that begins [count] bytes from the current position.
-func (s span) subspan(count int) span {
+// Return the number of bytes between the span's current position and the end of its slice.
+func (s span) remaining() int {
+	return len(s.bytes) - s.position
+}
+
+// Return a span that begins at the current position and ends [count] bytes later. Returns an error if fewer than [count] bytes remain.
+func (s span) subspan(count int) (span, error) {
+	if !s.hasBytes(count) {
+		return s, fmt.Errorf("can't take %d bytes at offset %d: only %d remain", count, s.position, s.remaining())
+	}
 	return span{
 		position: s.position,
 		bytes:    s.bytes[:s.position+count],
-	}
+	}, nil
 }
 
-// Return a byte slice that contains [count] bytes from the current position. This may be useful with dumpHex.
+// Return a byte slice that contains up to [count] bytes from the current position. This may be useful with dumpHex.
 func (s span) subslice(count int) []byte {
 	begin := s.position
-	end := min(len(s.bytes), s.position+count)
+	end := max(begin, min(len(s.bytes), s.position+count))
 	return s.bytes[begin:end]
 }
 
-// Return a string formed from [count] bytes that follow the current position.
-func (s span) substring(count int) string {
-	return string(s.bytes[s.position : s.position+count])
+// Return a string formed from [count] bytes that follow the current position. Returns an error if fewer than [count] bytes remain.
+func (s span) substring(count int) (string, error) {
+	if !s.hasBytes(count) {
+		return "", fmt.Errorf("can't read %d bytes at offset %d: only %d remain", count, s.position, s.remaining())
+	}
+	return string(s.bytes[s.position : s.position+count]), nil
 }
 
 // Check whether a span's position has reached the end of its slice.
 func (s span) empty() bool {
-	return s.position == len(s.bytes)
+	return s.position >= len(s.bytes)
 }
 
-// Check whether a span has at least [count] bytes remaining between its current position and the end of the slice.
+// Check whether a span has at least [count] bytes remaining between its current position and the end of the slice. Negative counts are never satisfied.
 func (s span) hasBytes(count int) bool {
-	return len(s.bytes) >= s.position+count
+	return count >= 0 && s.remaining() >= count
 }
 
 // Read a FOURCC (four byte) marker from a span's current position. Returns a span following the marker, the FOURCC value, and any error encountered.
@@ -62,12 +120,12 @@ func readFOURCC(s span) (span, int, error) {
 // Read a DWORD (four byte) value from a span's current position. Returns a span following the value, the value, and any error encountered.
 func readDWORD(s span) (span, int, error) {
 	if !s.hasBytes(4) {
-		return s, 0, fmt.Errorf("can't read DWORD")
+		return s, 0, fmt.Errorf("can't read DWORD at offset %d", s.position)
 	} else {
-		v := uint(s.at(0)) << 24
-		v |= uint(s.at(1)) << 16
-		v |= uint(s.at(2)) << 8
-		v |= uint(s.at(3))
+		v := uint32(s.at(0)) << 24
+		v |= uint32(s.at(1)) << 16
+		v |= uint32(s.at(2)) << 8
+		v |= uint32(s.at(3))
 		return s.advance(4), int(v), nil
 	}
 }
@@ -75,7 +133,7 @@ func readDWORD(s span) (span, int, error) {
 // Read a WORD (two byte) value from a span's current position. Returns a span following the value, the value, and any error encountered.
 func readWORD(s span) (span, int, error) {
 	if !s.hasBytes(2) {
-		return s, 0, fmt.Errorf("can't read WORD")
+		return s, 0, fmt.Errorf("can't read WORD at offset %d", s.position)
 	} else {
 		v := uint(s.at(0)) << 8
 		v |= uint(s.at(1))
@@ -83,6 +141,18 @@ func readWORD(s span) (span, int, error) {
 	}
 }
 
+// Read a big-endian double precision floating point value from a span's current position. Returns a span following the value, the value, and any error encountered.
+func readDouble(s span) (span, float64, error) {
+	if !s.hasBytes(8) {
+		return s, 0, fmt.Errorf("can't read double at offset %d", s.position)
+	}
+	var v uint64
+	for index := 0; index < 8; index++ {
+		v = v<<8 | uint64(s.at(index))
+	}
+	return s.advance(8), math.Float64frombits(v), nil
+}
+
 // Read a string from a span's current position. Returns a span following the string, the string, and any error encountered.
 // The string may include a null terminator and an encoding byte order marker. Use encodedString to decode the string.
 func readString(s span) (span, string, error) {
@@ -90,7 +160,10 @@ func readString(s span) (span, string, error) {
 	if error != nil {
 		return s, "", fmt.Errorf("reading string: %s", error)
 	}
-	text := textSpan.substring(length)
+	text, error := textSpan.substring(length)
+	if error != nil {
+		return s, "", fmt.Errorf("reading string: %s", error)
+	}
 	return textSpan.advance(length), text, nil
 }
 
@@ -101,7 +174,13 @@ func readNullTerminatedString(s span) (span, string, error) {
 	if error != nil {
 		return s, "", fmt.Errorf("reading string: %s", error)
 	}
-	text := textSpan.substring(length - 1)
+	if length < 1 {
+		return s, "", fmt.Errorf("reading string: invalid length %d at offset %d", length, s.position)
+	}
+	text, error := textSpan.substring(length - 1)
+	if error != nil {
+		return s, "", fmt.Errorf("reading string: %s", error)
+	}
 	return textSpan.advance(length), text, nil
 }
 
@@ -150,7 +229,7 @@ const (
 func scanRootChunk(s span) (chunkType, error) {
 	_, text, err := readString(s)
 	if err != nil {
-		return CT_Unknown, fmt.Errorf("error reading ROOT chunk: %s", err)
+		return CT_Unknown, err
 	}
 	switch text {
 	case "Version":
@@ -175,13 +254,17 @@ func isObjectIntro(value int) bool {
 	return value == 0xfffffffe || value == 0xffffffff
 }
 
-// Scans the contents of a Version ARCH chunk, and returns the project version string and any error encountered. If no version is found, an empty string is returned.
-func scanArchChunk_Version(s span) string {
+// Scans the contents of a Version ARCH chunk, and returns the name of the application that wrote the project (such as Cubase or Nuendo) and the project version string.
+// If no version is found, empty strings are returned.
+func scanArchChunk_Version(s span) (string, string) {
 	//fmt.Printf("scanArchChunk_Version\n")
 	//dumpHex(s.subslice(256))
 
 	for !s.empty() {
-		s2, objectIntro, _ := readDWORD(s)
+		s2, objectIntro, error := readDWORD(s)
+		if error != nil {
+			break
+		}
 		if isObjectIntro(objectIntro) {
 			var objectType string
 			s2, objectType, _ = readNullTerminatedString(s2)
@@ -190,28 +273,30 @@ func scanArchChunk_Version(s span) string {
 			case "CmObject":
 				s2, _, _ = readWORD(s2) // ignore
 			case "PAppVersion":
-				s2, _, _ = readWORD(s2)                 // ignore
-				s2, _, _ = readDWORD(s2)                // ignore
-				s2, _, _ = readNullTerminatedString(s2) // ignore
-				var version string
+				s2, _, _ = readWORD(s2)  // ignore
+				s2, _, _ = readDWORD(s2) // ignore
+				var application, version string
+				s2, application, _ = readNullTerminatedString(s2)
 				_, version, _ = readNullTerminatedString(s2)
-				return version
+				return application, version
 			}
 		}
 		s = s2
 	}
-	return ""
+	return "", ""
 }
 
 // Associates a named thing (a plugin or track) with a location.
 type namedLocation struct {
 	name     string
+	vendor   string // The vendor of a plugin, if known.
 	location span
 }
 
 // Scans an Arrangement or Devices ARCH chunk for information about plugins and the tracks on which they appear.
+// Plugins that don't follow a track are attributed to [defaultTrackName], or ignored if it is empty.
 // Results, if any, are stored in the projectInformation object passed in.
-func scanArchChunk(s span, pi *projectInformation) {
+func scanArchChunk(s span, pi *projectInformation, defaultTrackName string) {
 	//fmt.Printf("scanArchChunk\n")
 	//dumpHex(s.bytes[:256])
 
@@ -226,7 +311,7 @@ func scanArchChunk(s span, pi *projectInformation) {
 	// Associate tracks with plugins
 	for _, pluginLocation := range plugins {
 		// Find the name of the track immediately prior to the plugin location.
-		trackName := ""
+		trackName := defaultTrackName
 		for _, trackLocation := range tracks {
 			if trackLocation.location.position < pluginLocation.location.position {
 				trackName = trackLocation.name
@@ -237,6 +322,7 @@ func scanArchChunk(s span, pi *projectInformation) {
 
 		if len(trackName) != 0 {
 			pi.mapTrackToPlugin(pluginLocation.name, trackName)
+			pi.setPluginVendor(pluginLocation.name, pluginLocation.vendor)
 		}
 	}
 }
@@ -303,9 +389,16 @@ func findPlugins(s span) []namedLocation {
 						next, text, _ = readNullTerminatedString(next)
 						if text == "Original Plugin Name" {
 							next, _, _ = readWORD(next) // ignore
-							_, pluginName, _ = readString(next)
+							next, pluginName, _ = readString(next)
+							next, text, _ = readNullTerminatedString(next)
 						}
-						plugins = append(plugins, namedLocation{name: decodeString(pluginName), location: plugin})
+
+						var pluginVendor string
+						if text == "Plugin Vendor" {
+							next, _, _ = readWORD(next) // ignore
+							_, pluginVendor, _ = readString(next)
+						}
+						plugins = append(plugins, namedLocation{name: decodeString(pluginName), vendor: decodeString(pluginVendor), location: plugin})
 						//fmt.Printf("plugin: %s location %d\n", decodeString(pluginName), plugin.position)
 					}
 				}
@@ -315,6 +408,75 @@ func findPlugins(s span) []namedLocation {
 	return plugins
 }
 
+// An audio file found in the media pool of a CPR file.
+type cubaseMediaFile struct {
+	path     string // The absolute path of the file.
+	fileName string
+}
+
+// The distance after an FNPath record within which its file name and folder are looked for.
+const fnPathSearchLength = 1024
+
+// Finds and returns the audio files recorded in FNPath records in the given span. Each record holds the name of a file and the folder that contains it.
+// The layout of the records hasn't been documented, so this is a heuristic: the FileName and Path strings, in either order, are looked for within a short distance after each FNPath string,
+// and before the next one, so that a record without a folder doesn't take the folder of the record that follows it.
+func findMediaFiles(s span) []cubaseMediaFile {
+	files := []cubaseMediaFile{}
+
+	for record := findString(s, "FNPath"); !record.empty(); record = findString(record, "FNPath") {
+		end := min(len(record.bytes), record.position+fnPathSearchLength)
+		if next := findString(span{position: record.position, bytes: record.bytes[:end]}, "FNPath"); !next.empty() {
+			// The next record starts with the length of its FNPath string, which precedes the position after it.
+			end = next.position - len("FNPath") - 1 - 4
+		}
+		window := span{position: record.position, bytes: record.bytes[:end]}
+
+		var fileName, folder string
+		if next := findString(window, "FileName"); !next.empty() {
+			next, _, _ = readWORD(next) // ignore
+			_, fileName, _ = readString(next)
+			fileName = decodeString(fileName)
+		}
+		if next := findString(window, "Path"); !next.empty() {
+			next, _, _ = readWORD(next) // ignore
+			_, folder, _ = readString(next)
+			folder = decodeString(folder)
+		}
+
+		if len(fileName) != 0 {
+			path := fileName
+			if len(folder) != 0 {
+				path = strings.TrimRight(folder, `/\`) + "/" + fileName
+			}
+			files = append(files, cubaseMediaFile{path: path, fileName: fileName})
+		}
+	}
+	return files
+}
+
+// Finds the project setup recorded in the given span, and stores the sample rate, the bit depth at which audio is recorded and the tempo used when the tempo track is off in [metadata].
+// Each value follows its name and a WORD. Values that aren't found are left as they are.
+func findProjectSetup(s span, metadata *projectMetadata) {
+	if next := findString(s, "SampleRate"); !next.empty() {
+		next, _, _ = readWORD(next) // ignore
+		if _, value, err := readDWORD(next); err == nil {
+			metadata.sampleRate = value
+		}
+	}
+	if next := findString(s, "BitResolution"); !next.empty() {
+		next, _, _ = readWORD(next) // ignore
+		if _, value, err := readDWORD(next); err == nil {
+			metadata.bitDepth = value
+		}
+	}
+	if next := findString(s, "RehearsalTempo"); !next.empty() {
+		next, _, _ = readWORD(next) // ignore
+		if _, value, err := readDouble(next); err == nil && value > 0 && value < 1000 {
+			metadata.tempo = value
+		}
+	}
+}
+
 // Convert a string that might contain a null terminator followed by an encoding byte order marker into a string.
 func decodeString(text string) string {
 	for index, ch := range text {
@@ -328,80 +490,112 @@ func decodeString(text string) string {
 
 const (
 	riffFourcc = 0x52494646 // 'RIFF'
+	nundFourcc = 0x4e554e44 // 'NUND'
 	rootFourcc = 0x524f4f54 // 'ROOT'
 	archFourcc = 0x41524348 // 'ARCH'
 )
 
-// Examine the contents of a CPR file to obtain version information and a mapping of track names to plugin names.
+// Examine the contents of a CPR file, or of a Nuendo project (.npr) or Cubase track preset (.trackpreset) in the same format, to obtain version information and a mapping of track names to plugin names.
 func examineCPR(projectPath string) *projectInformation {
+	content, error := readProjectFile(projectPath)
+	if error != nil {
+		info := newProjectInformation(projectPath)
+		info.logError(newProjectError(EK_IO, error))
+		return &info
+	}
+	return examineCPRContent(projectPath, content)
+}
+
+// Examine the [content] of a CPR file to obtain version information and a mapping of track names to plugin names.
+func examineCPRContent(projectPath string, content []byte) *projectInformation {
 	info := newProjectInformation(projectPath)
 
-	content, error := os.ReadFile(projectPath)
+	s := span{bytes: content}
+	//dumpHex(s.bytes[:256])
+
+	s, riff, error := readFOURCC(s)
 	if error != nil {
-		info.logError(fmt.Sprintf("opening file %s", error.Error()))
-	} else {
-		s := span{bytes: content}
-		//dumpHex(s.bytes[:256])
+		info.logError(newProjectErrorAt(EK_TruncatedChunk, "RIFF", s.position, error))
+		return &info
+	}
+	if riff != riffFourcc {
+		info.logError(newProjectErrorAt(EK_BadRIFFMagic, "RIFF", 0, fmt.Errorf("found %q instead of \"RIFF\"", fourccToString(riff))))
+		return &info
+	}
+
+	s, riffSize, error := readDWORD(s)
+	maybeUnused(riffSize)
+	if error != nil {
+		info.logError(newProjectErrorAt(EK_TruncatedChunk, "RIFF", s.position, error))
+		return &info
+	}
+
+	s, formType, error := readFOURCC(s)
+	if error != nil {
+		info.logError(newProjectErrorAt(EK_TruncatedChunk, "RIFF", s.position, error))
+		return &info
+	}
+	// Projects written by other releases may use another form type but the same chunks, so they are examined anyway.
+	if formType != nundFourcc {
+		info.logWarning(fmt.Sprintf("the RIFF form type is %q instead of \"NUND\", so the project may not have been read fully", fourccToString(formType)))
+	}
+
+	lastRootChunkType := CT_Unknown
+	isTrackPreset := strings.EqualFold(filepath.Ext(projectPath), trackPresetExtension)
+	presetName := strings.TrimSuffix(filepath.Base(projectPath), filepath.Ext(projectPath))
 
-		s, riff, error := readFOURCC(s)
-		if error != nil || riff != riffFourcc {
-			info.logError(fmt.Sprintf("reading RIFF FOURCC: %s", error))
+	for !s.empty() {
+		chunkOffset := s.position
+		s2, chunkFourcc, error := readFOURCC(s)
+		if error != nil {
+			info.logError(newProjectErrorAt(EK_TruncatedChunk, "", chunkOffset, error))
 			return &info
 		}
-
-		s, riffSize, error := readDWORD(s)
-		maybeUnused(riffSize)
+		chunkName := fourccToString(chunkFourcc)
+		s2, chunkSize, error := readDWORD(s2)
 		if error != nil {
-			info.logError(fmt.Sprintf("reading RIFF chunk size: %s", error))
+			info.logError(newProjectErrorAt(EK_TruncatedChunk, chunkName, chunkOffset, error))
 			return &info
 		}
+		//fmt.Printf("Chunk: %s %d\n", chunkName, chunkSize)
 
-		s, formType, error := readFOURCC(s)
-		maybeUnused(formType)
+		cs, error := s2.subspan(chunkSize)
 		if error != nil {
-			info.logError(fmt.Sprintf("reading FORM FOURCC: %s", error))
+			info.logError(newProjectErrorAt(EK_TruncatedChunk, chunkName, chunkOffset, error))
 			return &info
 		}
 
-		lastRootChunkType := CT_Unknown
-
-		for !s.empty() {
-			s2, chunkFourcc, error := readFOURCC(s)
+		switch chunkFourcc {
+		case rootFourcc:
+			// Work out what type the ARCH chunk that follows this ROOT chunk will be.
+			lastRootChunkType, error = scanRootChunk(cs)
 			if error != nil {
-				info.logError(fmt.Sprintf("reading chunk FOURCC: %s", error))
+				info.logError(newProjectErrorAt(EK_TruncatedChunk, chunkName, chunkOffset, error))
 				return &info
 			}
-			s2, chunkSize, error := readDWORD(s2)
-			if error != nil {
-				info.logError(fmt.Sprintf("reading chunk size: %s", error))
-				return &info
-			}
-			//fmt.Printf("Chunk: %s %d\n", fourccToString(chunkFourcc), chunkSize)
-
-			switch chunkFourcc {
-			case rootFourcc:
-				// Work out what type the ARCH chunk that follows this ROOT chunk will be.
-				lastRootChunkType, error = scanRootChunk(s2.subspan(chunkSize))
-				if error != nil {
-					info.logError(fmt.Sprintf("reading ROOT chunk: %s", error))
-					return &info
+		case archFourcc:
+			// Process the ARCH chunk based on the type discovered in the preceeding ROOT chunk.
+			switch lastRootChunkType {
+			case CT_Version:
+				info.application, info.version = scanArchChunk_Version(cs)
+			case CT_Arrangement:
+				scanArchChunk(cs, &info, "")
+				findProjectSetup(cs, &info.metadata)
+				for _, media := range findMediaFiles(cs) {
+					// Cubase keeps the audio files recorded into or imported into a project in its Audio folder.
+					info.addMediaReference(media.path, "Audio/"+media.fileName)
 				}
-			case archFourcc:
-				// Process the ARCH chunk based on the type discovered in the preceeding ROOT chunk.
-				switch lastRootChunkType {
-				case CT_Version:
-					info.version = scanArchChunk_Version(s2.subspan(chunkSize))
-				case CT_Arrangement:
-					cs := s.subspan(chunkSize)
-					scanArchChunk(cs, &info)
-				case CT_Devices:
-					cs := s.subspan(chunkSize)
-					scanArchChunk(cs, &info)
+			case CT_Devices:
+				scanArchChunk(cs, &info, "")
+			default:
+				// Track presets store their tracks in chunks of other types, and may store plugins without a track.
+				if isTrackPreset {
+					scanArchChunk(cs, &info, presetName)
 				}
 			}
-
-			s = s2.advance(chunkSize)
 		}
+
+		s = s2.advance(chunkSize)
 	}
 	return &info
 }
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// Builds synthetic CPR content that mirrors the layout expected by examineCPR, findTracks and findPlugins.
+type cprBuilder struct {
+	bytes.Buffer
+}
+
+func (b *cprBuilder) dword(v uint32) *cprBuilder {
+	binary.Write(b, binary.BigEndian, v)
+	return b
+}
+
+func (b *cprBuilder) word(v uint16) *cprBuilder {
+	binary.Write(b, binary.BigEndian, v)
+	return b
+}
+
+// Write a length-prefixed, null-terminated string.
+func (b *cprBuilder) str(text string) *cprBuilder {
+	b.dword(uint32(len(text) + 1))
+	b.WriteString(text)
+	b.WriteByte(0)
+	return b
+}
+
+// Write the version object that scanArchChunk_Version looks for.
+func (b *cprBuilder) version(application, version string) *cprBuilder {
+	b.dword(0xffffffff).str("PAppVersion").word(0).dword(0).str(application).str(version)
+	return b
+}
+
+// Write a track record of the form found by findTracks.
+func (b *cprBuilder) track(trackType, name string) *cprBuilder {
+	b.str(trackType).dword(0).dword(0).dword(0)
+	b.str("RuntimeID").word(0).dword(0).dword(0)
+	b.str("Name").word(0).word(0).dword(0)
+	b.str("String").word(0).str(name)
+	return b
+}
+
+// Write a plugin record of the form found by findPlugins.
+func (b *cprBuilder) plugin(name string) *cprBuilder {
+	b.str("VstCtrlInternalEffect").str("Plugin").dword(0).word(0).word(0)
+	b.str("Plugin UID").dword(0).dword(0)
+	b.str("GUID").word(0).str("565354000000000000000000000000000")
+	b.str("Plugin Name").word(0).str(name)
+	b.str("Original Plugin Name").word(0).str(name)
+	return b
+}
+
+// Write a media pool record of the form found by findMediaFiles.
+func (b *cprBuilder) mediaFile(folder, fileName string) *cprBuilder {
+	b.str("FNPath").word(0).dword(0)
+	b.str("FileName").word(0).str(fileName)
+	b.str("Path").word(0).str(folder)
+	return b
+}
+
+// Write the project setup records found by findProjectSetup.
+func (b *cprBuilder) projectSetup(sampleRate, bitResolution uint32, tempo float64) *cprBuilder {
+	b.str("SampleRate").word(0).dword(sampleRate)
+	b.str("BitResolution").word(0).dword(bitResolution)
+	b.str("RehearsalTempo").word(0)
+	binary.Write(b, binary.BigEndian, tempo)
+	return b
+}
+
+// Wrap [data] in a chunk with the given FOURCC.
+func cprChunk(fourcc uint32, data []byte) []byte {
+	var b cprBuilder
+	b.dword(fourcc).dword(uint32(len(data)))
+	b.Write(data)
+	return b.Bytes()
+}
+
+// Wrap a sequence of ROOT/ARCH chunk pairs in a RIFF container.
+func cprFile(formType uint32, chunks ...[]byte) []byte {
+	var body cprBuilder
+	body.dword(formType)
+	for _, chunk := range chunks {
+		body.Write(chunk)
+	}
+	var b cprBuilder
+	b.dword(riffFourcc).dword(uint32(body.Len()))
+	b.Write(body.Bytes())
+	return b.Bytes()
+}
+
+// Create a ROOT chunk naming the type of the following ARCH chunk. Unlike most strings, the name is not null-terminated.
+func cprRoot(name string) []byte {
+	var b cprBuilder
+	b.dword(uint32(len(name)))
+	b.WriteString(name)
+	return cprChunk(rootFourcc, b.Bytes())
+}
+
+// Create a complete synthetic project with a version and a small arrangement.
+func syntheticCPR(application, version string) []byte {
+	var versionArch, arrangementArch cprBuilder
+	versionArch.version(application, version)
+	arrangementArch.track("VST Multitrack", "Bass").plugin("DUNE 3").plugin("StandardCLIP")
+	arrangementArch.track("VST Multitrack", "Pad").plugin("VPS Avenger")
+	arrangementArch.track("Output Channels", "Stereo Out").plugin("DSEQ3")
+	return cprFile(nundFourcc,
+		cprRoot("Version"), cprChunk(archFourcc, versionArch.Bytes()),
+		cprRoot("Arrangement1"), cprChunk(archFourcc, arrangementArch.Bytes()))
+}
+
+func TestSpanBounds(t *testing.T) {
+	s := span{bytes: []byte{0, 0, 0, 3, 'a', 'b'}}
+
+	if _, err := s.subspan(7); err == nil {
+		t.Errorf("Expected an error taking a subspan beyond the end of the slice")
+	}
+	if _, err := s.subspan(-1); err == nil {
+		t.Errorf("Expected an error taking a negative subspan")
+	}
+	if _, _, err := readString(s); err == nil {
+		t.Errorf("Expected an error reading a string whose length exceeds the remaining bytes")
+	}
+	if _, _, err := readNullTerminatedString(span{bytes: []byte{0, 0, 0, 0}}); err == nil {
+		t.Errorf("Expected an error reading a null-terminated string of zero length")
+	}
+	if _, _, err := readNullTerminatedString(span{bytes: []byte{0xff, 0xff, 0xff, 0xff, 'a'}}); err == nil {
+		t.Errorf("Expected an error reading a string with a huge length prefix")
+	}
+	if got := s.advance(100); !got.empty() {
+		t.Errorf("Expected advance to stop at the end of the slice, got position %d", got.position)
+	}
+	if got := s.advance(-100); got.position != s.position {
+		t.Errorf("Expected advance not to move backwards, got position %d", got.position)
+	}
+}
+
+func TestExamineCPRContent(t *testing.T) {
+	pi := examineCPRContent("synthetic.cpr", syntheticCPR("Cubase", "Version 13.0.21"))
+
+	if len(pi.errors) != 0 {
+		t.Fatalf("Unexpected errors: %v", pi.errors)
+	}
+	if pi.version != "Version 13.0.21" {
+		t.Errorf("Expected version %q, got %q", "Version 13.0.21", pi.version)
+	}
+	expected := map[string][]string{
+		"Bass":       {"DUNE 3", "StandardCLIP"},
+		"Pad":        {"VPS Avenger"},
+		"Stereo Out": {"DSEQ3"},
+	}
+	if !reflect.DeepEqual(pi.trackToPluginMap, expected) {
+		t.Errorf("Expected %v, got %v", expected, pi.trackToPluginMap)
+	}
+}
+
+func TestCubaseProjectFormat(t *testing.T) {
+	folder := t.TempDir()
+	tests := []struct {
+		file        string
+		application string
+		format      projectFormat
+	}{
+		{"Song.cpr", "Cubase", PF_CPR},
+		{"Film.npr", "Nuendo", PF_NPR},
+		// Nuendo projects are told apart by the application that wrote them rather than by their extension.
+		{"Film.cpr", "Nuendo", PF_NPR},
+	}
+	for _, test := range tests {
+		path := writeTestFile(t, filepath.Join(folder, test.file), syntheticCPR(test.application, "Version 13.0.21"))
+		pi := examineProject(path)
+		if pi == nil || pi.failed() {
+			t.Fatalf("%s: expected the project to be examined, got %v", test.file, pi)
+		}
+		if pi.format != test.format || pi.application != test.application {
+			t.Errorf("%s: expected %s %s, got %s %s", test.file, test.format, test.application, pi.format, pi.application)
+		}
+	}
+}
+
+func TestExamineCPRContentFormType(t *testing.T) {
+	content := syntheticCPR("Cubase", "Version 14.0.5")
+	copy(content[8:12], "NUEN")
+
+	pi := examineCPRContent("other.cpr", content)
+	if pi.failed() || len(pi.warnings) != 1 || len(pi.trackToPluginMap) != 3 {
+		t.Errorf("Expected the project to be examined with a warning about its form type, got %v, %v and %v", pi.trackToPluginMap, pi.warnings, pi.errors)
+	}
+}
+
+func TestExamineCPRContentDamaged(t *testing.T) {
+	content := syntheticCPR("Cubase", "Version 13.0.21")
+
+	for _, length := range []int{0, 3, 11, 20, len(content) / 2, len(content) - 1} {
+		pi := examineCPRContent("truncated.cpr", content[:length])
+		if len(pi.errors) != 1 || pi.errors[0].kind != EK_TruncatedChunk {
+			t.Errorf("Expected a truncated chunk error for content truncated to %d bytes, got %v", length, pi.errors)
+		}
+	}
+}
+
+// Seed a fuzz target with synthetic projects and damaged variants of them.
+func TestExamineCPRContentBadMagic(t *testing.T) {
+	content := syntheticCPR("Cubase", "Version 13.0.21")
+	content[0] = 'X'
+
+	pi := examineCPRContent("bad.cpr", content)
+	if len(pi.errors) != 1 || pi.errors[0].kind != EK_BadRIFFMagic || pi.errors[0].offset != 0 {
+		t.Errorf("Expected a bad RIFF magic error at offset 0, got %v", pi.errors)
+	}
+}
+
+func addCPRSeeds(f *testing.F) {
+	content := syntheticCPR("Cubase", "Version 12.0.70")
+	f.Add(content)
+	f.Add(content[:len(content)/2])
+	f.Add(content[:13])
+	f.Add([]byte("RIFF"))
+	f.Add([]byte{})
+	damaged := append([]byte{}, content...)
+	copy(damaged[12:], []byte{'R', 'O', 'O', 'T', 0x7f, 0xff, 0xff, 0xff})
+	f.Add(damaged)
+}
+
+func FuzzExamineCPR(f *testing.F) {
+	addCPRSeeds(f)
+	f.Fuzz(func(t *testing.T, content []byte) {
+		pi := examineCPRContent("fuzz.cpr", content)
+		if pi == nil {
+			t.Fatal("examineCPRContent returned nil")
+		}
+	})
+}
+
+func FuzzFindTracks(f *testing.F) {
+	addCPRSeeds(f)
+	f.Fuzz(func(t *testing.T, content []byte) {
+		for _, track := range findTracks(span{bytes: content}) {
+			if track.location.position > len(content) {
+				t.Errorf("Track %q located beyond the end of the content", track.name)
+			}
+		}
+	})
+}
+
+func FuzzFindPlugins(f *testing.F) {
+	addCPRSeeds(f)
+	f.Fuzz(func(t *testing.T, content []byte) {
+		for _, plugin := range findPlugins(span{bytes: content}) {
+			if plugin.location.position > len(content) {
+				t.Errorf("Plugin %q located beyond the end of the content", plugin.name)
+			}
+		}
+	})
+}
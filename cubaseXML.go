@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/MrSplidge/go-xmldom"
+)
+
+const trackArchiveExtension = ".xml"
+
+// Matches the root element of a Cubase track archive or XML track preset.
+var cubaseXMLRootPattern = regexp.MustCompile(`<(tracklist2?|trackpreset)[\s>]`)
+
+// The names of the root elements of Cubase track archives and XML track presets. The sniffer only looks for them in the header, where they may also appear within comments.
+var cubaseXMLRootNames = map[string]bool{"tracklist": true, "tracklist2": true, "trackpreset": true}
+
+// The parser for Cubase track archives, and track presets saved as XML, which are XML files whose root element is a track list or track preset.
+type cubaseXMLParser struct{}
+
+var PF_CubaseXML = registerProjectFormat(cubaseXMLParser{})
+
+func (cubaseXMLParser) Name() string { return "Cubase XML" }
+
+func (cubaseXMLParser) Extensions() []string { return []string{trackArchiveExtension} }
+
+func (cubaseXMLParser) Sniff(header []byte, content io.ReaderAt, size int64) bool {
+	return bytes.HasPrefix(trimTextHeader(header), []byte("<?xml")) && cubaseXMLRootPattern.Match(header)
+}
+
+func (cubaseXMLParser) Examine(path string) *projectInformation {
+	return examineCubaseXML(path)
+}
+
+// Given a plugin name DOM node in a Cubase track archive, find the name of the track within which it appears.
+// Tracks are obj elements whose class ends with TrackEvent, and their names are stored in a string element named Name.
+func findCubaseXMLTrackNameForNode(node *xmldom.Node) string {
+	for node = node.Parent; node != nil; node = node.Parent {
+		if node.Name == "obj" && strings.HasSuffix(node.GetAttributeValue("class"), "TrackEvent") {
+			for _, query := range []string{"obj[@name='Node']/string[@name='Name']", "string[@name='Name']"} {
+				if match := node.QueryOne(query); match != nil {
+					return match.GetAttributeValue("value")
+				}
+			}
+			return ""
+		}
+	}
+	return ""
+}
+
+// Return the value of a string element with the given name that is a sibling of [node], or an empty string if there isn't one.
+func cubaseXMLSiblingValue(node *xmldom.Node, name string) string {
+	if node.Parent != nil {
+		if sibling := node.Parent.QueryOne("string[@name='" + name + "']"); sibling != nil {
+			return sibling.GetAttributeValue("value")
+		}
+	}
+	return ""
+}
+
+// Examine the contents of a Cubase track archive (.xml) or XML track preset to obtain version information and a mapping of track names to plugin names.
+// Plugins that aren't within a track are attributed to a track named after the file. Returns nil if the root element isn't a track list or track preset, because other applications' XML files
+// can mention one in a comment within the header that the sniffer looks at.
+func examineCubaseXML(path string) *projectInformation {
+	info := newProjectInformation(path)
+
+	file, _, err := openProjectFile(path)
+	if err != nil {
+		info.logError(newProjectError(EK_IO, err))
+		return &info
+	}
+	defer file.Close()
+
+	dom, err := xmldom.Parse(file)
+	if err != nil {
+		var syntaxError *xml.SyntaxError
+		if errors.As(err, &syntaxError) {
+			info.logError(newProjectError(EK_MalformedXML, err))
+		} else {
+			info.logError(newProjectError(EK_IO, err))
+		}
+		return &info
+	}
+	if dom.Root == nil || !cubaseXMLRootNames[dom.Root.Name] {
+		return nil
+	}
+
+	// Extract the version of the application that wrote the file, if it was recorded.
+	if version := dom.Root.QueryOne("//obj[@class='PAppVersion']/string[@name='Version']"); version != nil {
+		info.version = version.GetAttributeValue("value")
+	}
+
+	presetName := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	// Extract a mapping of track names to plugins, preferring the original name of each plugin as the CPR parser does.
+	for _, node := range dom.Root.Query("//string[@name='Plugin Name']") {
+		plugin := cubaseXMLSiblingValue(node, "Original Plugin Name")
+		if len(plugin) == 0 {
+			plugin = node.GetAttributeValue("value")
+		}
+		if len(plugin) == 0 {
+			continue
+		}
+		track := findCubaseXMLTrackNameForNode(node)
+		if len(track) == 0 {
+			track = presetName
+		}
+		info.mapTrackToPlugin(plugin, track)
+		info.setPluginVendor(plugin, cubaseXMLSiblingValue(node, "Plugin Vendor"))
+	}
+
+	return &info
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testTrackArchive = `<?xml version="1.0" encoding="utf-8"?>
+<tracklist2>
+	<list name="track" type="obj">
+		<obj class="MAudioTrackEvent" ID="1">
+			<obj class="MListNode" name="Node" ID="2">
+				<string name="Name" value="Vox" wide="true"/>
+			</obj>
+			<member name="DeviceAttributes">
+				<list name="Inserts" type="obj">
+					<obj class="VstCtrlInternalEffect" ID="3">
+						<member name="Plugin">
+							<string name="Plugin Name" value="Pro-Q 3"/>
+							<string name="Original Plugin Name" value="FabFilter Pro-Q 3"/>
+							<string name="Plugin Vendor" value="FabFilter"/>
+						</member>
+					</obj>
+				</list>
+			</member>
+		</obj>
+		<obj class="MInstrumentTrackEvent" ID="4">
+			<obj class="MListNode" name="Node" ID="5">
+				<string name="Name" value="Bass" wide="true"/>
+			</obj>
+			<member name="Instrument">
+				<string name="Plugin Name" value="DUNE 3"/>
+			</member>
+		</obj>
+	</list>
+	<obj class="PAppVersion" name="Version">
+		<string name="Application" value="Cubase"/>
+		<string name="Version" value="Version 13.0.21"/>
+	</obj>
+</tracklist2>`
+
+func TestExamineCubaseXML(t *testing.T) {
+	path := writeTestFile(t, filepath.Join(t.TempDir(), "Archive.xml"), []byte(testTrackArchive))
+
+	if format := chooseProjectFormat(path); format != PF_CubaseXML {
+		t.Fatalf("Expected a Cubase XML file, got %s", format)
+	}
+
+	pi := examineProject(path)
+	if pi.failed() {
+		t.Fatalf("Unexpected errors: %v", pi.errors)
+	}
+	if pi.version != "Version 13.0.21" {
+		t.Errorf("Expected version %q, got %q", "Version 13.0.21", pi.version)
+	}
+	expected := map[string][]string{"Vox": {"FabFilter Pro-Q 3"}, "Bass": {"DUNE 3"}}
+	if !reflect.DeepEqual(pi.trackToPluginMap, expected) {
+		t.Errorf("Expected %v, got %v", expected, pi.trackToPluginMap)
+	}
+	if vendor := pi.pluginVendors["FabFilter Pro-Q 3"]; vendor != "FabFilter" {
+		t.Errorf("Expected vendor FabFilter, got %q", vendor)
+	}
+
+	if format := chooseProjectFormat(writeTestFile(t, filepath.Join(t.TempDir(), "Other.xml"), []byte(`<?xml version="1.0"?><svg/>`))); format != PF_Unknown {
+		t.Errorf("Expected other XML files not to be recognised, got %s", format)
+	}
+}
+
+func TestExamineOtherXML(t *testing.T) {
+	folder := t.TempDir()
+
+	// XML files from other applications that mention a track list in their header aren't projects, so they are skipped rather than reported as damaged.
+	paths := []string{
+		writeTestFile(t, filepath.Join(folder, "Comment.xml"), []byte("<?xml version=\"1.0\"?>\n<!-- <tracklist> -->")),
+		writeTestFile(t, filepath.Join(folder, "Commented.xml"), []byte("<?xml version=\"1.0\"?>\n<!-- <tracklist> --><svg/>")),
+	}
+	for _, path := range paths {
+		if pi := examineProject(path); pi != nil {
+			t.Errorf("%s: expected the file to be skipped, got %+v", path, pi)
+		}
+	}
+	so := scanOptions{numThreads: 1}
+	if exitCode := so.examine(paths, func(*projectInformation) {}); exitCode != 0 {
+		t.Errorf("Expected exit code 0 when XML files are skipped, got %d", exitCode)
+	}
+}
+
+func TestExamineTrackPreset(t *testing.T) {
+	var presetArch cprBuilder
+	presetArch.plugin("ValhallaShimmer").plugin("Blackhole")
+	content := cprFile(nundFourcc, cprRoot("Version"), cprChunk(archFourcc, (&cprBuilder{}).version("Cubase", "Version 12.0.70").Bytes()),
+		cprRoot("TrackPreset"), cprChunk(archFourcc, presetArch.Bytes()))
+	path := writeTestFile(t, filepath.Join(t.TempDir(), "Big Verb.trackpreset"), content)
+
+	pi := examineProject(path)
+	if pi == nil || pi.failed() {
+		t.Fatalf("Unexpected result: %+v", pi)
+	}
+	expected := map[string][]string{"Big Verb": {"ValhallaShimmer", "Blackhole"}}
+	if !reflect.DeepEqual(pi.trackToPluginMap, expected) {
+		t.Errorf("Expected %v, got %v", expected, pi.trackToPluginMap)
+	}
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+)
+
+// The minimum proportion of plugins that a removed track and an added track must share to be considered a rename.
+const renameSimilarityThreshold = 0.5
+
+// A track whose name changed between two projects.
+type trackRename struct {
+	from string
+	to   string
+}
+
+// A plugin that was removed from one track and added to another.
+type pluginMove struct {
+	plugin string
+	from   string
+	to     string
+}
+
+// The plugins added to and removed from a track that appears in both projects.
+type trackPluginChange struct {
+	track   string
+	added   []string
+	removed []string
+}
+
+// The differences between the tracks and plugins of two projects. Tracks that were renamed are identified by their name in the [after] project.
+type projectDiff struct {
+	before         *projectInformation
+	after          *projectInformation
+	tracksAdded    []string
+	tracksRemoved  []string
+	tracksRenamed  []trackRename
+	trackChanges   []trackPluginChange
+	pluginsMoved   []pluginMove
+	pluginsAdded   []string
+	pluginsRemoved []string
+}
+
+// Return the plugins used on a track, sorted case-insensitively and de-duplicated.
+func pluginsOnTrack(pi *projectInformation, track string) []string {
+	return sortedCIUnique(pi.trackToPluginMap[track])
+}
+
+// Return the keys of a map that don't appear in another map, sorted case-insensitively.
+func keysNotIn(m, other map[string][]string) []string {
+	keys := []string{}
+	iterateOverCISortedMap(m, func(key string, _ []string) {
+		if _, found := other[key]; !found {
+			keys = append(keys, key)
+		}
+	})
+	return keys
+}
+
+// Calculate the proportion of plugins that two sorted, de-duplicated plugin lists share.
+func pluginSimilarity(a, b []string) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for _, plugin := range a {
+		if slices.Contains(b, plugin) {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// Compare the tracks and plugins of two projects.
+func diffProjects(before, after *projectInformation) projectDiff {
+	pd := projectDiff{before: before, after: after}
+
+	pd.pluginsAdded = keysNotIn(after.pluginToTrackMap, before.pluginToTrackMap)
+	pd.pluginsRemoved = keysNotIn(before.pluginToTrackMap, after.pluginToTrackMap)
+
+	// Pair each removed track with the added track whose plugins are most similar, treating the pair as a rename.
+	removed := keysNotIn(before.trackToPluginMap, after.trackToPluginMap)
+	added := keysNotIn(after.trackToPluginMap, before.trackToPluginMap)
+	for _, from := range removed {
+		bestIndex, bestSimilarity := -1, renameSimilarityThreshold
+		for index, to := range added {
+			if similarity := pluginSimilarity(pluginsOnTrack(before, from), pluginsOnTrack(after, to)); similarity >= bestSimilarity {
+				if bestIndex < 0 || similarity > bestSimilarity {
+					bestIndex, bestSimilarity = index, similarity
+				}
+			}
+		}
+		if bestIndex >= 0 {
+			pd.tracksRenamed = append(pd.tracksRenamed, trackRename{from: from, to: added[bestIndex]})
+			added = slices.Delete(added, bestIndex, bestIndex+1)
+		} else {
+			pd.tracksRemoved = append(pd.tracksRemoved, from)
+		}
+	}
+	pd.tracksAdded = added
+
+	// Map each track in the after project that also exists in the before project to its name there.
+	beforeNames := map[string]string{}
+	for track := range after.trackToPluginMap {
+		if _, found := before.trackToPluginMap[track]; found {
+			beforeNames[track] = track
+		}
+	}
+	for _, rename := range pd.tracksRenamed {
+		beforeNames[rename.to] = rename.from
+	}
+
+	// Find the plugins added to and removed from each surviving track.
+	type trackPlugin struct {
+		track  string
+		plugin string
+	}
+	additions, removals := []trackPlugin{}, []trackPlugin{}
+	iterateOverCISortedMap(beforeNames, func(track, beforeName string) {
+		beforePlugins, afterPlugins := pluginsOnTrack(before, beforeName), pluginsOnTrack(after, track)
+		for _, plugin := range afterPlugins {
+			if !slices.Contains(beforePlugins, plugin) {
+				additions = append(additions, trackPlugin{track: track, plugin: plugin})
+			}
+		}
+		for _, plugin := range beforePlugins {
+			if !slices.Contains(afterPlugins, plugin) {
+				removals = append(removals, trackPlugin{track: track, plugin: plugin})
+			}
+		}
+	})
+
+	// A plugin that was removed from one track and added to another has moved.
+	for index := 0; index < len(removals); index++ {
+		removal := removals[index]
+		moveIndex := slices.IndexFunc(additions, func(addition trackPlugin) bool {
+			return addition.plugin == removal.plugin
+		})
+		if moveIndex >= 0 {
+			pd.pluginsMoved = append(pd.pluginsMoved, pluginMove{plugin: removal.plugin, from: beforeNames[removal.track], to: additions[moveIndex].track})
+			additions = slices.Delete(additions, moveIndex, moveIndex+1)
+			removals = slices.Delete(removals, index, index+1)
+			index--
+		}
+	}
+
+	// Group the remaining additions and removals by track.
+	changes := map[string]*trackPluginChange{}
+	change := func(track string) *trackPluginChange {
+		if _, found := changes[track]; !found {
+			changes[track] = &trackPluginChange{track: track}
+		}
+		return changes[track]
+	}
+	for _, addition := range additions {
+		change(addition.track).added = append(change(addition.track).added, addition.plugin)
+	}
+	for _, removal := range removals {
+		change(removal.track).removed = append(change(removal.track).removed, removal.plugin)
+	}
+	iterateOverCISortedMap(changes, func(_ string, tpc *trackPluginChange) {
+		pd.trackChanges = append(pd.trackChanges, *tpc)
+	})
+
+	sort.Slice(pd.tracksRenamed, func(i, j int) bool {
+		return strings.ToLower(pd.tracksRenamed[i].to) < strings.ToLower(pd.tracksRenamed[j].to)
+	})
+	return pd
+}
+
+// Check whether the two projects have the same tracks and plugins.
+func (pd *projectDiff) empty() bool {
+	return len(pd.tracksAdded) == 0 && len(pd.tracksRemoved) == 0 && len(pd.tracksRenamed) == 0 &&
+		len(pd.trackChanges) == 0 && len(pd.pluginsMoved) == 0 && len(pd.pluginsAdded) == 0 && len(pd.pluginsRemoved) == 0
+}
+
+// Generate a coloured description of the differences between two projects.
+func (pd *projectDiff) ColouredString(projectColour, keyColour, addedColour, removedColour, resetColour string) string {
+	var sb strings.Builder
+
+	sb.WriteString(projectColour + "--- " + pd.before.path + " (" + pd.before.version + ")\n")
+	sb.WriteString("+++ " + pd.after.path + " (" + pd.after.version + ")" + resetColour + "\n\n")
+
+	if pd.empty() {
+		sb.WriteString("The projects have the same tracks and plugins.\n")
+		return sb.String()
+	}
+
+	pluginList := func(plugins []string) string {
+		if len(plugins) == 0 {
+			return ""
+		}
+		return " [ " + strings.Join(plugins, ", ") + " ]"
+	}
+
+	if len(pd.tracksAdded)+len(pd.tracksRemoved)+len(pd.tracksRenamed) != 0 {
+		sb.WriteString("Tracks:\n")
+		for _, track := range pd.tracksRemoved {
+			sb.WriteString("  " + removedColour + "- " + track + resetColour + pluginList(pluginsOnTrack(pd.before, track)) + "\n")
+		}
+		for _, track := range pd.tracksAdded {
+			sb.WriteString("  " + addedColour + "+ " + track + resetColour + pluginList(pluginsOnTrack(pd.after, track)) + "\n")
+		}
+		for _, rename := range pd.tracksRenamed {
+			sb.WriteString("  " + keyColour + "~ " + rename.from + " -> " + rename.to + resetColour + "\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(pd.trackChanges)+len(pd.pluginsMoved) != 0 {
+		sb.WriteString("Plugins changed on tracks:\n")
+		for _, tpc := range pd.trackChanges {
+			sb.WriteString("  " + keyColour + tpc.track + resetColour + "\n")
+			for _, plugin := range tpc.removed {
+				sb.WriteString("    " + removedColour + "- " + plugin + resetColour + "\n")
+			}
+			for _, plugin := range tpc.added {
+				sb.WriteString("    " + addedColour + "+ " + plugin + resetColour + "\n")
+			}
+		}
+		for _, move := range pd.pluginsMoved {
+			sb.WriteString("  " + keyColour + move.plugin + resetColour + " moved from " + move.from + " to " + move.to + "\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(pd.pluginsAdded)+len(pd.pluginsRemoved) != 0 {
+		sb.WriteString("Plugins used by the project:\n")
+		for _, plugin := range pd.pluginsRemoved {
+			sb.WriteString("  " + removedColour + "- " + plugin + resetColour + "\n")
+		}
+		for _, plugin := range pd.pluginsAdded {
+			sb.WriteString("  " + addedColour + "+ " + plugin + resetColour + "\n")
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// Generate a coloured or monochrome description of the differences based on whether stdout is a terminal or a file.
+func (pd *projectDiff) String() string {
+	isAtty := isatty.IsTerminal(os.Stdout.Fd())
+	if isAtty {
+		return pd.ColouredString(yellow, cyan, green, red, reset)
+	} else {
+		return pd.ColouredString("", "", "", "", "")
+	}
+}
+
+// Run the diff command, which compares the tracks and plugins of two project files, in any mix of formats.
+// The exit code is 0 if the projects have the same tracks and plugins, 1 if they differ, and 2 if either project can't be examined.
+func runDiff(args []string) int {
+	fs := newCommandFlagSet("diff", "<project> <project>")
+	var timeoutFlag = fs.Duration("timeout", defaultTimeout, "The maximum time to spend examining each project file (0 for no limit).")
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 2
+	}
+
+	projects := []*projectInformation{}
+	for _, path := range fs.Args() {
+		pi := examineIsolated(path, *timeoutFlag, examineProject)
+		if pi == nil {
+			fmt.Fprintf(os.Stderr, "%s is not a supported project file\n", path)
+			return 2
+		}
+		if pi.failed() {
+			fmt.Print(pi.String())
+			return 2
+		}
+		projects = append(projects, pi)
+	}
+
+	pd := diffProjects(projects[0], projects[1])
+	fmt.Print(pd.String())
+	if pd.empty() {
+		return 0
+	}
+	return 1
+}
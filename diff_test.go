@@ -0,0 +1,65 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Create a project from a map of track names to plugin names, in the way that the parsers record them, so a plugin used more than once on a track is listed more than once.
+func projectWithTracks(path string, tracks map[string][]string) *projectInformation {
+	pi := newProjectInformation(path)
+	for track, plugins := range tracks {
+		for _, plugin := range plugins {
+			pi.mapTrackToPlugin(plugin, track)
+		}
+	}
+	return &pi
+}
+
+func TestDiffProjects(t *testing.T) {
+	before := projectWithTracks("92.cpr", map[string][]string{
+		"Group 01":   {"ValhallaShimmer"},
+		"Chime":      {"Chromaphone 3"},
+		"Perc":       {"Chromaphone 3", "DSEQ3", "Chromaphone 3"},
+		"Stereo Out": {"Newfangled Elevate"},
+		"Old Pad":    {"VPS Avenger"},
+	})
+	after := projectWithTracks("92-02.cpr", map[string][]string{
+		"Group 01":   {"Blackhole"},
+		"Chimes":     {"Chromaphone 3"},
+		"Perc":       {"Chromaphone 3"},
+		"Stereo Out": {"Newfangled Elevate", "DSEQ3"},
+		"Bass":       {"DUNE 3", "bx_oneknob"},
+	})
+
+	pd := diffProjects(before, after)
+
+	if !reflect.DeepEqual(pd.tracksRenamed, []trackRename{{from: "Chime", to: "Chimes"}}) {
+		t.Errorf("Unexpected renames: %v", pd.tracksRenamed)
+	}
+	if !reflect.DeepEqual(pd.tracksAdded, []string{"Bass"}) || !reflect.DeepEqual(pd.tracksRemoved, []string{"Old Pad"}) {
+		t.Errorf("Unexpected added %v or removed %v tracks", pd.tracksAdded, pd.tracksRemoved)
+	}
+	expectedChanges := []trackPluginChange{{track: "Group 01", added: []string{"Blackhole"}, removed: []string{"ValhallaShimmer"}}}
+	if !reflect.DeepEqual(pd.trackChanges, expectedChanges) {
+		t.Errorf("Expected track changes %v, got %v", expectedChanges, pd.trackChanges)
+	}
+	if !reflect.DeepEqual(pd.pluginsMoved, []pluginMove{{plugin: "DSEQ3", from: "Perc", to: "Stereo Out"}}) {
+		t.Errorf("Unexpected moves: %v", pd.pluginsMoved)
+	}
+	if !reflect.DeepEqual(pd.pluginsAdded, []string{"Blackhole", "bx_oneknob", "DUNE 3"}) || !reflect.DeepEqual(pd.pluginsRemoved, []string{"ValhallaShimmer", "VPS Avenger"}) {
+		t.Errorf("Unexpected added %v or removed %v plugins", pd.pluginsAdded, pd.pluginsRemoved)
+	}
+
+	if same := diffProjects(before, before); !same.empty() {
+		t.Errorf("Expected no differences between a project and itself, got %+v", same)
+	}
+}
+
+func TestPluginsOnTrack(t *testing.T) {
+	pi := projectWithTracks("Mix.als", map[string][]string{"Bass": {"DUNE 3", "bx_oneknob", "Pro-Q 3", "bx_oneknob"}})
+
+	if plugins, expected := pluginsOnTrack(pi, "Bass"), []string{"bx_oneknob", "DUNE 3", "Pro-Q 3"}; !reflect.DeepEqual(plugins, expected) {
+		t.Errorf("Expected %v, got %v", expected, plugins)
+	}
+}
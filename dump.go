@@ -0,0 +1,215 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+)
+
+// Print the decompressed XML content of an ALS file.
+func dumpALS(path string) error {
+	file, _, err := openProjectFile(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	gzipReader, err := gzip.NewReader(bufio.NewReader(file))
+	if err != nil {
+		return err
+	}
+	defer gzipReader.Close()
+
+	_, err = io.Copy(os.Stdout, gzipReader)
+	return err
+}
+
+// Print the content of a file that is already human-readable.
+func dumpFile(path string) error {
+	file, _, err := openProjectFile(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(os.Stdout, file)
+	return err
+}
+
+// Print the chunks within a CPR file, with the type named by each ROOT chunk. If [hexBytes] is greater than zero, up to that many bytes of each chunk are also printed in hexadecimal.
+func dumpCPR(path string, hexBytes int) error {
+	content, err := readProjectFile(path)
+	if err != nil {
+		return err
+	}
+
+	s := span{bytes: content}
+	s, riff, err := readFOURCC(s)
+	if err != nil {
+		return err
+	}
+	s, riffSize, err := readDWORD(s)
+	if err != nil {
+		return err
+	}
+	s, formType, err := readFOURCC(s)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%08x %s %d %s\n", 0, fourccToString(riff), riffSize, fourccToString(formType))
+
+	for !s.empty() {
+		chunkOffset := s.position
+		s2, chunkFourcc, err := readFOURCC(s)
+		if err != nil {
+			return err
+		}
+		s2, chunkSize, err := readDWORD(s2)
+		if err != nil {
+			return err
+		}
+		cs, err := s2.subspan(chunkSize)
+		if err != nil {
+			return err
+		}
+
+		description := ""
+		if chunkFourcc == rootFourcc {
+			if _, text, err := readString(cs); err == nil {
+				description = " " + decodeString(text)
+			}
+		}
+		fmt.Printf("%08x   %s %d%s\n", chunkOffset, fourccToString(chunkFourcc), chunkSize, description)
+		if hexBytes > 0 {
+			dumpHex(cs.subslice(hexBytes))
+		}
+
+		s = s2.advance(chunkSize)
+	}
+	return nil
+}
+
+// Print the events within an FL Studio project file. Fixed-size events are printed with their values, and variable-length events with their lengths. If [hexBytes] is greater than zero, up to that many bytes of each variable-length event are also printed in hexadecimal.
+func dumpFLP(path string, hexBytes int) error {
+	content, err := readProjectFile(path)
+	if err != nil {
+		return err
+	}
+
+	s := span{bytes: content}
+	for !s.empty() {
+		chunkOffset := s.position
+		s2, chunkFourcc, err := readFOURCC(s)
+		if err != nil {
+			return err
+		}
+		s2, chunkSize, err := readDWORDLE(s2)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%08x %s %d\n", chunkOffset, fourccToString(chunkFourcc), chunkSize)
+
+		if chunkFourcc != fldtFourcc {
+			if !s2.hasBytes(chunkSize) {
+				return fmt.Errorf("can't skip %d bytes at offset %d", chunkSize, s2.position)
+			}
+			s = s2.advance(chunkSize)
+			continue
+		}
+
+		events, err := s2.subspan(chunkSize)
+		if err != nil {
+			return err
+		}
+		for !events.empty() {
+			eventOffset := events.position
+			var id, value int
+			var data []byte
+			if events, id, value, data, err = readFLPEvent(events); err != nil {
+				return err
+			}
+			if id < 192 {
+				fmt.Printf("%08x   %3d = %d\n", eventOffset, id, value)
+			} else {
+				fmt.Printf("%08x   %3d [%d bytes]\n", eventOffset, id, value)
+				if hexBytes > 0 {
+					dumpHex(data[:min(len(data), hexBytes)])
+				}
+			}
+		}
+		s = s2.advance(chunkSize)
+	}
+	return nil
+}
+
+// Print the entries within a Studio One song, followed by the content of each XML document within it.
+func dumpSong(songPath string) error {
+	reader, size, err := openProjectFile(songPath)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	archive, err := zip.NewReader(reader, size)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range archive.File {
+		fmt.Printf("%s %d\n", file.Name, file.UncompressedSize64)
+	}
+	for _, file := range archive.File {
+		if path.Ext(file.Name) != ".xml" {
+			continue
+		}
+		fmt.Printf("\n%s:\n", file.Name)
+		reader, err := file.Open()
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(os.Stdout, reader)
+		reader.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run the dump command, which prints the internal structure of a project file. This is useful when adding support for new project versions.
+func runDump(args []string) int {
+	fs := newCommandFlagSet("dump", "[flags] <project>")
+	var hexFlag = fs.Int("hex", 0, "For CPR and FLP files, the number of bytes of each chunk or event to print in hexadecimal.")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	path := fs.Arg(0)
+	var err error
+	switch chooseProjectFormat(path) {
+	case PF_ALS:
+		err = dumpALS(path)
+	case PF_CPR, PF_NPR:
+		err = dumpCPR(path, *hexFlag)
+	case PF_FLP:
+		err = dumpFLP(path, *hexFlag)
+	case PF_Song:
+		err = dumpSong(path)
+	case PF_CubaseXML, PF_RPP, PF_Ardour:
+		err = dumpFile(path)
+	default:
+		err = fmt.Errorf("not a supported project file")
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "dumping %s: %s\n", path, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,441 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// A query expression that can be evaluated against a project. Expressions have this grammar:
+//
+//	expression := term { "or" term }
+//	term       := factor { "and" factor }
+//	factor     := "not" factor | "(" expression ")" | condition
+//	condition  := field operator value
+//	operator   := ":" | "=" | "!=" | "<" | "<=" | ">" | ">="
+//
+// The text fields are plugin, track, vendor, version and path. The ":" operator matches a pattern as the query flags do, and "=" and "!=" compare whole names, ignoring case.
+// The plugin, track and vendor fields are true if any plugin, track or vendor in the project satisfies the condition. The version field can also be compared numerically with
+// "<", "<=", ">" and ">=", in which case only as many numeric components as the value has are compared. The plugins, tracks and errors fields are counts that can be compared
+// with "=", "!=", "<", "<=", ">" and ">=". Values that contain spaces or operator characters must be enclosed in double quotes.
+type queryExpr interface {
+	eval(pi *projectInformation) bool
+}
+
+// A syntax error in a query expression, with the position at which it was found.
+type exprSyntaxError struct {
+	column int // The 1-based column at which the error was found.
+	text   string
+}
+
+// Describe the syntax error and its position.
+func (ese *exprSyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at column %d: %s", ese.column, ese.text)
+}
+
+// Kinds of token in a query expression.
+type tokenKind int
+
+const (
+	TK_Word tokenKind = iota
+	TK_String
+	TK_Operator
+	TK_LeftParen
+	TK_RightParen
+	TK_End
+)
+
+// A token in a query expression.
+type exprToken struct {
+	kind   tokenKind
+	text   string
+	column int
+}
+
+// Describe a token for use in error messages.
+func (et exprToken) String() string {
+	switch et.kind {
+	case TK_End:
+		return "the end of the expression"
+	case TK_String:
+		return strconv.Quote(et.text)
+	default:
+		return "'" + et.text + "'"
+	}
+}
+
+// Check whether a token is the given keyword, ignoring case.
+func (et exprToken) isKeyword(keyword string) bool {
+	return et.kind == TK_Word && strings.EqualFold(et.text, keyword)
+}
+
+// Split a query expression into tokens.
+func tokenizeExpr(text string) ([]exprToken, error) {
+	tokens := []exprToken{}
+	runes := []rune(text)
+
+	for i := 0; i < len(runes); {
+		ch := runes[i]
+		column := i + 1
+		switch {
+		case unicode.IsSpace(ch):
+			i++
+		case ch == '(':
+			tokens = append(tokens, exprToken{kind: TK_LeftParen, text: "(", column: column})
+			i++
+		case ch == ')':
+			tokens = append(tokens, exprToken{kind: TK_RightParen, text: ")", column: column})
+			i++
+		case ch == ':' || ch == '=':
+			tokens = append(tokens, exprToken{kind: TK_Operator, text: string(ch), column: column})
+			i++
+		case ch == '!' || ch == '<' || ch == '>':
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				tokens = append(tokens, exprToken{kind: TK_Operator, text: string(ch) + "=", column: column})
+				i += 2
+			} else if ch == '!' {
+				return nil, &exprSyntaxError{column: column, text: "expected '=' after '!'"}
+			} else {
+				tokens = append(tokens, exprToken{kind: TK_Operator, text: string(ch), column: column})
+				i++
+			}
+		case ch == '"':
+			var sb strings.Builder
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				sb.WriteRune(runes[i])
+			}
+			if i == len(runes) {
+				return nil, &exprSyntaxError{column: column, text: "unterminated string"}
+			}
+			i++
+			tokens = append(tokens, exprToken{kind: TK_String, text: sb.String(), column: column})
+		default:
+			start := i
+			for ; i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune("()\":=!<>", runes[i]); i++ {
+			}
+			tokens = append(tokens, exprToken{kind: TK_Word, text: string(runes[start:i]), column: column})
+		}
+	}
+
+	return append(tokens, exprToken{kind: TK_End, column: len(runes) + 1}), nil
+}
+
+// A recursive descent parser for query expressions.
+type exprParser struct {
+	tokens   []exprToken
+	position int
+}
+
+// Return the current token without consuming it.
+func (ep *exprParser) peek() exprToken {
+	return ep.tokens[ep.position]
+}
+
+// Consume and return the current token.
+func (ep *exprParser) next() exprToken {
+	token := ep.tokens[ep.position]
+	if token.kind != TK_End {
+		ep.position++
+	}
+	return token
+}
+
+// Parse a query expression.
+func parseExpr(text string) (queryExpr, error) {
+	tokens, err := tokenizeExpr(text)
+	if err != nil {
+		return nil, err
+	}
+	ep := exprParser{tokens: tokens}
+	expr, err := ep.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if token := ep.peek(); token.kind != TK_End {
+		return nil, &exprSyntaxError{column: token.column, text: fmt.Sprintf("expected 'and', 'or' or the end of the expression, found %s", token)}
+	}
+	return expr, nil
+}
+
+// Parse a sequence of terms separated by "or".
+func (ep *exprParser) parseOr() (queryExpr, error) {
+	left, err := ep.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for ep.peek().isKeyword("or") {
+		ep.next()
+		right, err := ep.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+// Parse a sequence of factors separated by "and".
+func (ep *exprParser) parseAnd() (queryExpr, error) {
+	left, err := ep.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for ep.peek().isKeyword("and") {
+		ep.next()
+		right, err := ep.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &andExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+// Parse a factor, which is a negated factor, a parenthesized expression or a condition.
+func (ep *exprParser) parseNot() (queryExpr, error) {
+	token := ep.peek()
+	switch {
+	case token.isKeyword("not"):
+		ep.next()
+		operand, err := ep.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{operand: operand}, nil
+	case token.kind == TK_LeftParen:
+		ep.next()
+		expr, err := ep.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := ep.next(); closing.kind != TK_RightParen {
+			return nil, &exprSyntaxError{column: closing.column, text: fmt.Sprintf("expected ')' to match the '(' at column %d, found %s", token.column, closing)}
+		}
+		return expr, nil
+	default:
+		return ep.parseCondition()
+	}
+}
+
+// The fields that can appear in a condition, and whether each one is a count.
+var exprFields = map[string]bool{
+	"plugin":  false,
+	"track":   false,
+	"vendor":  false,
+	"version": false,
+	"path":    false,
+	"plugins": true,
+	"tracks":  true,
+	"errors":  true,
+}
+
+// Parse a condition of the form field operator value.
+func (ep *exprParser) parseCondition() (queryExpr, error) {
+	fieldToken := ep.next()
+	if fieldToken.kind != TK_Word {
+		return nil, &exprSyntaxError{column: fieldToken.column, text: fmt.Sprintf("expected a field name, found %s", fieldToken)}
+	}
+	field := strings.ToLower(fieldToken.text)
+	isCount, found := exprFields[field]
+	if !found {
+		return nil, &exprSyntaxError{column: fieldToken.column, text: fmt.Sprintf("unknown field %s (expected plugin, track, vendor, version, path, plugins, tracks or errors)", fieldToken)}
+	}
+
+	opToken := ep.next()
+	if opToken.kind != TK_Operator {
+		return nil, &exprSyntaxError{column: opToken.column, text: fmt.Sprintf("expected an operator after '%s', found %s", fieldToken.text, opToken)}
+	}
+
+	valueToken := ep.next()
+	if valueToken.kind != TK_Word && valueToken.kind != TK_String {
+		return nil, &exprSyntaxError{column: valueToken.column, text: fmt.Sprintf("expected a value after '%s%s', found %s", fieldToken.text, opToken.text, valueToken)}
+	}
+
+	ce := &conditionExpr{field: field, op: opToken.text, value: valueToken.text}
+	ordered := strings.ContainsAny(ce.op, "<>")
+
+	switch {
+	case isCount:
+		if ce.op == ":" {
+			return nil, &exprSyntaxError{column: opToken.column, text: fmt.Sprintf("'%s' is a count and can't be matched with ':'", field)}
+		}
+		number, err := strconv.Atoi(ce.value)
+		if err != nil {
+			return nil, &exprSyntaxError{column: valueToken.column, text: fmt.Sprintf("expected a number, found %s", valueToken)}
+		}
+		ce.number = number
+	case ordered:
+		if field != "version" {
+			return nil, &exprSyntaxError{column: opToken.column, text: fmt.Sprintf("'%s' can only be compared with ':', '=' or '!='", field)}
+		}
+		ce.version = versionComponents(ce.value)
+		if len(ce.version) == 0 {
+			return nil, &exprSyntaxError{column: valueToken.column, text: fmt.Sprintf("expected a version number, found %s", valueToken)}
+		}
+	case ce.op == ":":
+		matcher, err := newTextMatcher(ce.value)
+		if err != nil {
+			return nil, &exprSyntaxError{column: valueToken.column, text: err.Error()}
+		}
+		ce.matcher = matcher
+	default:
+		ce.matcher = func(text string) bool {
+			return strings.EqualFold(text, ce.value)
+		}
+	}
+	return ce, nil
+}
+
+// An expression that is true if both of its operands are true.
+type andExpr struct {
+	left, right queryExpr
+}
+
+func (ae *andExpr) eval(pi *projectInformation) bool {
+	return ae.left.eval(pi) && ae.right.eval(pi)
+}
+
+// An expression that is true if either of its operands is true.
+type orExpr struct {
+	left, right queryExpr
+}
+
+func (oe *orExpr) eval(pi *projectInformation) bool {
+	return oe.left.eval(pi) || oe.right.eval(pi)
+}
+
+// An expression that is true if its operand is false.
+type notExpr struct {
+	operand queryExpr
+}
+
+func (ne *notExpr) eval(pi *projectInformation) bool {
+	return !ne.operand.eval(pi)
+}
+
+// A condition that compares a field of a project with a value.
+type conditionExpr struct {
+	field   string
+	op      string
+	value   string
+	matcher textMatcher // Used by ":", "=" and "!=" on text fields.
+	version []int       // Used by ordered comparisons of the version field.
+	number  int         // Used by comparisons of counts.
+}
+
+// Return the values of a text field for a project.
+func (ce *conditionExpr) textValues(pi *projectInformation) []string {
+	values := []string{}
+	switch ce.field {
+	case "plugin":
+		for plugin := range pi.pluginToTrackMap {
+			values = append(values, plugin)
+		}
+	case "track":
+		for track := range pi.trackToPluginMap {
+			values = append(values, track)
+		}
+	case "vendor":
+		for _, vendor := range pi.pluginVendors {
+			values = append(values, vendor)
+		}
+	case "version":
+		values = append(values, pi.version)
+	case "path":
+		values = append(values, pi.path)
+	}
+	return values
+}
+
+// Return the value of a count field for a project.
+func (ce *conditionExpr) countValue(pi *projectInformation) int {
+	switch ce.field {
+	case "plugins":
+		return len(pi.pluginToTrackMap)
+	case "tracks":
+		return len(pi.trackToPluginMap)
+	case "errors":
+		return len(pi.errors)
+	}
+	return 0
+}
+
+func (ce *conditionExpr) eval(pi *projectInformation) bool {
+	if isCount := exprFields[ce.field]; isCount {
+		return compareWith(ce.op, ce.countValue(pi)-ce.number)
+	}
+
+	if ce.matcher == nil {
+		// A version that couldn't be read, such as "<unknown version>", has no numeric components, and is neither before nor after any version.
+		version := versionComponents(pi.version)
+		if len(version) == 0 {
+			return false
+		}
+		return compareWith(ce.op, compareVersions(version, ce.version))
+	}
+
+	anyMatch := false
+	for _, value := range ce.textValues(pi) {
+		if ce.matcher(value) {
+			anyMatch = true
+			break
+		}
+	}
+	if ce.op == "!=" {
+		return !anyMatch
+	}
+	return anyMatch
+}
+
+// Interpret the result of a three-way comparison according to an operator.
+func compareWith(op string, comparison int) bool {
+	switch op {
+	case "=":
+		return comparison == 0
+	case "!=":
+		return comparison != 0
+	case "<":
+		return comparison < 0
+	case "<=":
+		return comparison <= 0
+	case ">":
+		return comparison > 0
+	case ">=":
+		return comparison >= 0
+	}
+	return false
+}
+
+var versionComponentPattern = regexp.MustCompile(`[0-9]+`)
+
+// Extract the numeric components of a version string, so that "Version 12.0.70" becomes [12 0 70] and "11.0_11300" becomes [11 0 11300].
+func versionComponents(version string) []int {
+	components := []int{}
+	for _, text := range versionComponentPattern.FindAllString(version, -1) {
+		if number, err := strconv.Atoi(text); err == nil {
+			components = append(components, number)
+		}
+	}
+	return components
+}
+
+// Compare a version with a [reference] version, considering only as many components as the reference has.
+// Missing components in the version are treated as zero. Returns a negative number, zero or a positive number.
+func compareVersions(version, reference []int) int {
+	for index, want := range reference {
+		have := 0
+		if index < len(version) {
+			have = version[index]
+		}
+		if have != want {
+			return have - want
+		}
+	}
+	return 0
+}
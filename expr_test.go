@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseExpr(t *testing.T) {
+	pi := newProjectInformation("C:\\Music\\Sets\\43\\43.als")
+	pi.version = "11.0_11300"
+	pi.mapTrackToPlugin("VPS Avenger", "8-VPS Avenger")
+	pi.mapTrackToPlugin("DUNE 3", "5 D3.RBass1")
+	pi.mapTrackToPlugin("StandardCLIP", "5 D3.RBass1")
+	pi.setPluginVendor("DUNE 3", "Synapse Audio")
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`plugin:"VPS Avenger" and not plugin:"Surge XT" and version>=11.0`, true},
+		{`plugin:"VPS Avenger" and plugin:"Surge XT"`, false},
+		{`plugin:"Surge XT" or track:*Bass*`, true},
+		{`not (plugin:dune or plugin:avenger)`, false},
+		{`plugin=dune`, false},
+		{`plugin="dune 3"`, true},
+		{`plugin!="dune 3"`, false},
+		{`vendor:synapse`, true},
+		{`version>=11.1`, false},
+		{`version<12`, true},
+		{`version:11.0_`, true},
+		{`path:*.als`, true},
+		{`plugins=3 and tracks>=2 and errors=0`, true},
+		{`plugins>3`, false},
+		{`PLUGIN:"/^std|^standard/" AND NOT track:Master`, true},
+	}
+	for _, test := range tests {
+		expr, err := parseExpr(test.expr)
+		if err != nil {
+			t.Errorf("parseExpr(%q) failed: %v", test.expr, err)
+			continue
+		}
+		if got := expr.eval(&pi); got != test.want {
+			t.Errorf("%q: expected %v, got %v", test.expr, test.want, got)
+		}
+	}
+}
+
+func TestParseExprSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		expr   string
+		column int
+	}{
+		{``, 1},
+		{`plugin`, 7},
+		{`plugin:`, 8},
+		{`colour:red`, 1},
+		{`plugin:"VPS Avenger`, 8},
+		{`(plugin:a or plugin:b`, 22},
+		{`plugin:a plugin:b`, 10},
+		{`plugins:3`, 8},
+		{`plugins>many`, 9},
+		{`track>=10`, 6},
+		{`version>=latest`, 10},
+		{`plugin!a`, 7},
+		{`plugin:"/(/"`, 8},
+		{`not`, 4},
+	}
+	for _, test := range tests {
+		_, err := parseExpr(test.expr)
+		var syntaxError *exprSyntaxError
+		if !errors.As(err, &syntaxError) {
+			t.Errorf("%q: expected a syntax error, got %v", test.expr, err)
+			continue
+		}
+		if syntaxError.column != test.column {
+			t.Errorf("%q: expected an error at column %d, got %v", test.expr, test.column, err)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	if compareVersions(versionComponents("Version 12.0.70"), versionComponents("12.0")) != 0 {
+		t.Errorf("Expected 12.0.70 to equal 12.0 when comparing two components")
+	}
+	if compareVersions(versionComponents("11.0_11300"), versionComponents("11.1")) >= 0 {
+		t.Errorf("Expected 11.0_11300 to be less than 11.1")
+	}
+}
+
+func TestCompareUnknownVersion(t *testing.T) {
+	pi := newProjectInformation("Damaged.cpr")
+
+	tests := map[string]bool{
+		`version<11`:     false,
+		`version<=11`:    false,
+		`version>0`:      false,
+		`version>=0`:     false,
+		`version=0`:      false,
+		`version!=11`:    true,
+		`not version<11`: true,
+	}
+	for text, want := range tests {
+		expr, err := parseExpr(text)
+		if err != nil {
+			t.Fatalf("parseExpr(%q) failed: %v", text, err)
+		}
+		if got := expr.eval(&pi); got != want {
+			t.Errorf("%q with an unknown version: expected %v, got %v", text, want, got)
+		}
+	}
+}
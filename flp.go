@@ -0,0 +1,401 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// Read a little-endian DWORD (four byte) value from a span's current position. Returns a span following the value, the value, and any error encountered.
+func readDWORDLE(s span) (span, int, error) {
+	if !s.hasBytes(4) {
+		return s, 0, fmt.Errorf("can't read DWORD at offset %d", s.position)
+	} else {
+		v := uint32(s.at(0))
+		v |= uint32(s.at(1)) << 8
+		v |= uint32(s.at(2)) << 16
+		v |= uint32(s.at(3)) << 24
+		return s.advance(4), int(v), nil
+	}
+}
+
+// Read a little-endian WORD (two byte) value from a span's current position. Returns a span following the value, the value, and any error encountered.
+func readWORDLE(s span) (span, int, error) {
+	if !s.hasBytes(2) {
+		return s, 0, fmt.Errorf("can't read WORD at offset %d", s.position)
+	} else {
+		v := uint(s.at(0))
+		v |= uint(s.at(1)) << 8
+		return s.advance(2), int(v), nil
+	}
+}
+
+// Read a little-endian QWORD (eight byte) value from a span's current position. Returns a span following the value, the value, and any error encountered.
+// Values larger than the span's slice are reported as errors, since they are only ever used as lengths.
+func readQWORDLE(s span) (span, int, error) {
+	if !s.hasBytes(8) {
+		return s, 0, fmt.Errorf("can't read QWORD at offset %d", s.position)
+	}
+	var v uint64
+	for index := 7; index >= 0; index-- {
+		v = v<<8 | uint64(s.at(index))
+	}
+	if v > uint64(len(s.bytes)) {
+		return s, 0, fmt.Errorf("QWORD %d at offset %d is too large", v, s.position)
+	}
+	return s.advance(8), int(v), nil
+}
+
+// Read a variable-length integer from a span's current position. Each byte holds seven bits of the value, least significant first, and the top bit is set on all but the last byte.
+// Returns a span following the value, the value, and any error encountered.
+func readVarint(s span) (span, int, error) {
+	v := 0
+	for shift := 0; shift < 32; shift += 7 {
+		if !s.hasBytes(1) {
+			return s, 0, fmt.Errorf("can't read variable-length integer at offset %d", s.position)
+		}
+		b := s.at(0)
+		s = s.advance(1)
+		v |= int(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return s, v, nil
+		}
+	}
+	return s, 0, fmt.Errorf("variable-length integer at offset %d is too long", s.position)
+}
+
+const flpExtension = ".flp"
+
+// The parser for FL Studio projects, which are binary files that begin with an FLhd chunk.
+type flpParser struct{}
+
+var PF_FLP = registerProjectFormat(flpParser{})
+
+func (flpParser) Name() string { return "FLP" }
+
+func (flpParser) Extensions() []string { return []string{flpExtension} }
+
+func (flpParser) Sniff(header []byte, content io.ReaderAt, size int64) bool {
+	return bytes.HasPrefix(header, []byte("FLhd"))
+}
+
+func (flpParser) Examine(path string) *projectInformation {
+	return examineFLP(path)
+}
+
+const (
+	flhdFourcc = 0x464c6864 // 'FLhd'
+	fldtFourcc = 0x464c6474 // 'FLdt'
+)
+
+// The IDs of the FL Studio events that are used to find plugins and project settings. Events 0-63 have a byte value, 64-127 a WORD value, 128-191 a DWORD value, and 192-255 a variable-length value.
+const (
+	FE_TimeSignatureNumerator = 17  // The number of beats in a bar of the project's time signature.
+	FE_TimeSignatureBeat      = 18  // The note value of a beat in the project's time signature.
+	FE_ChannelNew             = 64  // Begins the events for a channel in the channel rack.
+	FE_SlotIndex              = 98  // Ends the events for an effect slot in a mixer insert.
+	FE_InsertOutput           = 147 // Ends the events for a mixer insert.
+	FE_Tempo                  = 156 // The project's tempo, in thousandths of a beat per minute.
+	FE_Version                = 199 // The version of FL Studio that saved the project.
+	FE_PluginInternalName     = 201 // The name of the plugin that FL Studio uses internally, such as "Fruity Wrapper" for wrapped VST plugins.
+	FE_PluginName             = 203 // The name of a plugin as shown to the user, which is also the name of a channel.
+	FE_InsertName             = 204 // The name of a mixer insert.
+	FE_PluginWrapperParams    = 213 // The state of a plugin, which includes the name and vendor of wrapped VST plugins.
+	FE_InsertFlags            = 236 // Begins the events for a mixer insert.
+)
+
+// The IDs of the properties stored in the state of a wrapped VST plugin.
+const (
+	flpWrapperName   = 54
+	flpWrapperVendor = 56
+)
+
+// The internal name of the FL Studio plugin that hosts VST plugins.
+const flpWrapperInternalName = "Fruity Wrapper"
+
+// The first version of FL Studio that stores text as UTF-16.
+var flpUnicodeVersion = []int{11, 5}
+
+// A plugin found in an FL Studio project.
+type flpPlugin struct {
+	internalName string
+	displayName  string
+	wrapperName  string
+	vendor       string
+}
+
+// Return the name by which a plugin is reported: the name of a wrapped VST plugin, or the internal name of a native FL Studio plugin.
+func (p flpPlugin) name() string {
+	if len(p.wrapperName) != 0 {
+		return p.wrapperName
+	}
+	if p.internalName == flpWrapperInternalName {
+		return p.displayName
+	}
+	return p.internalName
+}
+
+// Decode the text of an FL Studio event, which is UTF-16 in projects saved by recent versions and single-byte text before that. Any null terminator is removed.
+func decodeFLPText(data []byte, unicode bool) string {
+	if unicode {
+		units := make([]uint16, 0, len(data)/2)
+		for index := 0; index+1 < len(data); index += 2 {
+			units = append(units, uint16(data[index])|uint16(data[index+1])<<8)
+		}
+		return decodeString(string(utf16.Decode(units)))
+	}
+	return decodeString(string(data))
+}
+
+// Parse the state of a wrapped VST plugin to find its name and vendor. The state begins with a DWORD giving its kind, followed by properties that each have a DWORD ID, a QWORD size and a value.
+// Older kinds of state don't contain properties, and nothing is returned for them.
+func parseFLPWrapperParams(data []byte) (string, string) {
+	s, kind, err := readDWORDLE(span{bytes: data})
+	if err != nil || (kind != 8 && kind != 10) {
+		return "", ""
+	}
+
+	var name, vendor string
+	for !s.empty() {
+		var id, size int
+		if s, id, err = readDWORDLE(s); err != nil {
+			break
+		}
+		if s, size, err = readQWORDLE(s); err != nil {
+			break
+		}
+		value, err := s.substring(size)
+		if err != nil {
+			break
+		}
+		switch id {
+		case flpWrapperName:
+			name = decodeString(value)
+		case flpWrapperVendor:
+			vendor = decodeString(value)
+		}
+		s = s.advance(size)
+	}
+	return name, vendor
+}
+
+// Read an FL Studio event from a span's current position. Returns a span following the event, the event ID, its value (for fixed-size events) or data (for variable-length events), and any error encountered.
+func readFLPEvent(s span) (span, int, int, []byte, error) {
+	if !s.hasBytes(1) {
+		return s, 0, 0, nil, fmt.Errorf("can't read event at offset %d", s.position)
+	}
+	id := int(s.at(0))
+	next := s.advance(1)
+
+	var value int
+	var data []byte
+	var err error
+	switch {
+	case id < 64:
+		if !next.hasBytes(1) {
+			err = fmt.Errorf("can't read byte at offset %d", next.position)
+		} else {
+			value, next = int(next.at(0)), next.advance(1)
+		}
+	case id < 128:
+		next, value, err = readWORDLE(next)
+	case id < 192:
+		next, value, err = readDWORDLE(next)
+	default:
+		if next, value, err = readVarint(next); err == nil {
+			if !next.hasBytes(value) {
+				err = fmt.Errorf("can't read %d bytes at offset %d: only %d remain", value, next.position, next.remaining())
+			} else {
+				data, next = next.subslice(value), next.advance(value)
+			}
+		}
+	}
+	if err != nil {
+		return s, id, 0, nil, fmt.Errorf("event %d: %s", id, err)
+	}
+	return next, id, value, data, nil
+}
+
+// Compare the components of an FL Studio version string, such as "20.8.4.2576", with [minimum]. Returns true if the version is at least [minimum].
+func flpVersionAtLeast(version string, minimum []int) bool {
+	components := strings.Split(version, ".")
+	for index, required := range minimum {
+		if index >= len(components) {
+			return false
+		}
+		value, err := strconv.Atoi(components[index])
+		if err != nil || value != required {
+			return err == nil && value > required
+		}
+	}
+	return true
+}
+
+// Examine the contents of an FL Studio project file to obtain version information and a mapping of track names to plugin names.
+// Generators in the channel rack are listed against the names of their channels, and effects against the names of the mixer inserts in which they appear.
+func examineFLP(projectPath string) *projectInformation {
+	content, err := readProjectFile(projectPath)
+	if err != nil {
+		info := newProjectInformation(projectPath)
+		info.logError(newProjectError(EK_IO, err))
+		return &info
+	}
+	return examineFLPContent(projectPath, content)
+}
+
+// Examine the [content] of an FL Studio project file to obtain version information and a mapping of track names to plugin names.
+func examineFLPContent(projectPath string, content []byte) *projectInformation {
+	info := newProjectInformation(projectPath)
+	info.application = "FL Studio"
+
+	// The header chunk holds the format of the file, the number of channels and the timebase.
+	s, fourcc, err := readFOURCC(span{bytes: content})
+	if err != nil {
+		info.logError(newProjectErrorAt(EK_TruncatedChunk, "FLhd", s.position, err))
+		return &info
+	}
+	if fourcc != flhdFourcc {
+		info.logError(newProjectErrorAt(EK_BadHeader, "FLhd", 0, fmt.Errorf("found %q instead of \"FLhd\"", fourccToString(fourcc))))
+		return &info
+	}
+	s, headerSize, err := readDWORDLE(s)
+	if err == nil && !s.hasBytes(headerSize) {
+		err = fmt.Errorf("can't skip %d bytes at offset %d: only %d remain", headerSize, s.position, s.remaining())
+	}
+	if err != nil {
+		info.logError(newProjectErrorAt(EK_TruncatedChunk, "FLhd", s.position, err))
+		return &info
+	}
+	s = s.advance(headerSize)
+
+	// The data chunk holds the events that describe the project.
+	dataOffset := s.position
+	s, fourcc, err = readFOURCC(s)
+	if err != nil {
+		info.logError(newProjectErrorAt(EK_TruncatedChunk, "FLdt", s.position, err))
+		return &info
+	}
+	if fourcc != fldtFourcc {
+		info.logError(newProjectErrorAt(EK_BadHeader, "FLdt", dataOffset, fmt.Errorf("found %q instead of \"FLdt\"", fourccToString(fourcc))))
+		return &info
+	}
+	s, dataSize, err := readDWORDLE(s)
+	if err != nil {
+		info.logError(newProjectErrorAt(EK_TruncatedChunk, "FLdt", s.position, err))
+		return &info
+	}
+	if !s.hasBytes(dataSize) {
+		info.logError(newProjectErrorAt(EK_TruncatedChunk, "FLdt", dataOffset, fmt.Errorf("the chunk is %d bytes long but only %d remain", dataSize, s.remaining())))
+		dataSize = s.remaining()
+	}
+	s, _ = s.subspan(dataSize)
+
+	unicode := false
+	inMixer := false
+	insertNumber := 0
+	var channel, slot *flpPlugin
+	var insertName string
+	var insertPlugins []flpPlugin
+
+	// Record the generator of the current channel, if it has one.
+	finishChannel := func() {
+		if channel != nil {
+			if name := channel.name(); len(name) != 0 {
+				// Channels are named after their generators unless the user renames them.
+				track := channel.displayName
+				if len(track) == 0 {
+					track = name
+				}
+				info.mapTrackToPlugin(name, track)
+				info.setPluginVendor(name, channel.vendor)
+			}
+			channel = nil
+		}
+	}
+
+	// The plugin that the plugin events currently apply to: the generator of the current channel, or the effect in the current mixer slot.
+	currentPlugin := func() *flpPlugin {
+		if inMixer {
+			if slot == nil {
+				slot = &flpPlugin{}
+			}
+			return slot
+		}
+		if channel == nil {
+			channel = &flpPlugin{}
+		}
+		return channel
+	}
+
+	for !s.empty() {
+		eventOffset := s.position
+		var id, value int
+		var data []byte
+		if s, id, value, data, err = readFLPEvent(s); err != nil {
+			info.logError(newProjectErrorAt(EK_TruncatedChunk, "FLdt", eventOffset, err))
+			break
+		}
+
+		switch id {
+		case FE_Tempo:
+			info.metadata.tempo = float64(value) / 1000
+		case FE_TimeSignatureNumerator, FE_TimeSignatureBeat:
+			// Only the project's time signature precedes the channels and the mixer.
+			if channel == nil && !inMixer {
+				if id == FE_TimeSignatureNumerator {
+					info.metadata.numerator = value
+				} else {
+					info.metadata.denominator = value
+				}
+			}
+		case FE_Version:
+			info.version = decodeString(string(data))
+			unicode = flpVersionAtLeast(info.version, flpUnicodeVersion)
+		case FE_ChannelNew:
+			finishChannel()
+			inMixer = false
+			channel = &flpPlugin{}
+		case FE_PluginInternalName:
+			currentPlugin().internalName = decodeFLPText(data, unicode)
+		case FE_PluginName:
+			currentPlugin().displayName = decodeFLPText(data, unicode)
+		case FE_PluginWrapperParams:
+			plugin := currentPlugin()
+			plugin.wrapperName, plugin.vendor = parseFLPWrapperParams(data)
+		case FE_InsertFlags, FE_InsertName, FE_SlotIndex, FE_InsertOutput:
+			if !inMixer {
+				finishChannel()
+				inMixer = true
+			}
+			switch id {
+			case FE_InsertName:
+				insertName = decodeFLPText(data, unicode)
+			case FE_SlotIndex:
+				if slot != nil && len(slot.name()) != 0 {
+					insertPlugins = append(insertPlugins, *slot)
+				}
+				slot = nil
+			case FE_InsertOutput:
+				// Insert 0 is the master insert.
+				track := insertName
+				if len(track) == 0 && insertNumber == 0 {
+					track = "Master"
+				} else if len(track) == 0 {
+					track = fmt.Sprintf("Insert %d", insertNumber)
+				}
+				for _, plugin := range insertPlugins {
+					info.mapTrackToPlugin(plugin.name(), track)
+					info.setPluginVendor(plugin.name(), plugin.vendor)
+				}
+				insertNumber++
+				insertName, insertPlugins, slot = "", nil, nil
+			}
+		}
+	}
+	finishChannel()
+
+	return &info
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"unicode/utf16"
+)
+
+// Builds synthetic FLP events that mirror the layout expected by examineFLP.
+type flpBuilder struct {
+	bytes.Buffer
+	unicode bool
+}
+
+func (b *flpBuilder) byteValue(id byte, v uint8) *flpBuilder {
+	b.WriteByte(id)
+	b.WriteByte(v)
+	return b
+}
+
+func (b *flpBuilder) word(id byte, v uint16) *flpBuilder {
+	b.WriteByte(id)
+	binary.Write(b, binary.LittleEndian, v)
+	return b
+}
+
+func (b *flpBuilder) dword(id byte, v uint32) *flpBuilder {
+	b.WriteByte(id)
+	binary.Write(b, binary.LittleEndian, v)
+	return b
+}
+
+// Write a variable-length event.
+func (b *flpBuilder) data(id byte, data []byte) *flpBuilder {
+	b.WriteByte(id)
+	for length := len(data); ; length >>= 7 {
+		if length < 0x80 {
+			b.WriteByte(byte(length))
+			break
+		}
+		b.WriteByte(byte(length&0x7f) | 0x80)
+	}
+	b.Write(data)
+	return b
+}
+
+// Write a null-terminated text event, encoded as UTF-16 if the builder is for a recent version.
+func (b *flpBuilder) text(id byte, text string) *flpBuilder {
+	if !b.unicode {
+		return b.data(id, append([]byte(text), 0))
+	}
+	var encoded bytes.Buffer
+	binary.Write(&encoded, binary.LittleEndian, utf16.Encode([]rune(text+"\x00")))
+	return b.data(id, encoded.Bytes())
+}
+
+// Write the state of a wrapped VST plugin with the given name and vendor.
+func (b *flpBuilder) wrapper(name, vendor string) *flpBuilder {
+	var state bytes.Buffer
+	binary.Write(&state, binary.LittleEndian, uint32(10))
+	for _, property := range []struct {
+		id    uint32
+		value string
+	}{{1, "\x00\x00\x00\x00"}, {flpWrapperName, name}, {flpWrapperVendor, vendor}} {
+		binary.Write(&state, binary.LittleEndian, property.id)
+		binary.Write(&state, binary.LittleEndian, uint64(len(property.value)))
+		state.WriteString(property.value)
+	}
+	return b.data(FE_PluginWrapperParams, state.Bytes())
+}
+
+// Wrap [events] in FLhd and FLdt chunks.
+func flpFile(events []byte) []byte {
+	var b bytes.Buffer
+	b.WriteString("FLhd")
+	binary.Write(&b, binary.LittleEndian, uint32(6))
+	binary.Write(&b, binary.LittleEndian, []uint16{0, 3, 96})
+	b.WriteString("FLdt")
+	binary.Write(&b, binary.LittleEndian, uint32(len(events)))
+	b.Write(events)
+	return b.Bytes()
+}
+
+// Build a project with a wrapped VST generator, a sampler, a native generator and three mixer inserts.
+func syntheticFLP(version string, unicode bool) []byte {
+	b := flpBuilder{unicode: unicode}
+	b.data(FE_Version, append([]byte(version), 0))
+	b.word(FE_ChannelNew, 0).text(FE_PluginInternalName, flpWrapperInternalName).text(FE_PluginName, "Lead").wrapper("Serum", "Xfer Records")
+	b.word(FE_ChannelNew, 1).text(FE_PluginName, "Kick")
+	b.word(FE_ChannelNew, 2).text(FE_PluginInternalName, "Sytrus")
+	b.data(FE_InsertFlags, make([]byte, 12)).text(FE_PluginInternalName, "Fruity Limiter").word(FE_SlotIndex, 0).dword(FE_InsertOutput, 0xffffffff)
+	b.data(FE_InsertFlags, make([]byte, 12)).text(FE_InsertName, "Drums")
+	b.text(FE_PluginInternalName, flpWrapperInternalName).text(FE_PluginName, "EQ").wrapper("Pro-Q 3", "FabFilter").word(FE_SlotIndex, 0)
+	b.word(FE_SlotIndex, 1).dword(FE_InsertOutput, 0)
+	b.data(FE_InsertFlags, make([]byte, 12)).text(FE_PluginInternalName, "Fruity Reeverb 2").word(FE_SlotIndex, 0).dword(FE_InsertOutput, 0)
+	return flpFile(b.Bytes())
+}
+
+func TestExamineFLP(t *testing.T) {
+	for _, test := range []struct {
+		version string
+		unicode bool
+	}{{"20.8.4.2576", true}, {"11.1.1", false}} {
+		path := writeTestFile(t, filepath.Join(t.TempDir(), "Beat.flp"), syntheticFLP(test.version, test.unicode))
+
+		if format := chooseProjectFormat(path); format != PF_FLP {
+			t.Fatalf("Expected an FLP file, got %s", format)
+		}
+
+		pi := examineProject(path)
+		if pi.failed() {
+			t.Fatalf("Unexpected errors: %v", pi.errors)
+		}
+		if pi.application != "FL Studio" || pi.version != test.version {
+			t.Errorf("Expected FL Studio %s, got %q %q", test.version, pi.application, pi.version)
+		}
+		expected := map[string][]string{
+			"Lead":     {"Serum"},
+			"Sytrus":   {"Sytrus"},
+			"Master":   {"Fruity Limiter"},
+			"Drums":    {"Pro-Q 3"},
+			"Insert 2": {"Fruity Reeverb 2"},
+		}
+		if !reflect.DeepEqual(pi.trackToPluginMap, expected) {
+			t.Errorf("Expected %v, got %v", expected, pi.trackToPluginMap)
+		}
+		if vendor := pi.pluginVendors["Pro-Q 3"]; vendor != "FabFilter" {
+			t.Errorf("Expected vendor FabFilter, got %q", vendor)
+		}
+	}
+}
+
+func TestExamineFLPErrors(t *testing.T) {
+	content := syntheticFLP("20.8.4.2576", true)
+
+	pi := examineFLPContent("Bad.flp", append([]byte("MThd"), content[4:]...))
+	if len(pi.errors) != 1 || pi.errors[0].kind != EK_BadHeader || pi.errors[0].chunk != "FLhd" {
+		t.Errorf("Expected a bad header error, got %v", pi.errors)
+	}
+
+	pi = examineFLPContent("Truncated.flp", content[:len(content)-5])
+	if len(pi.errors) == 0 || pi.errors[0].kind != EK_TruncatedChunk {
+		t.Errorf("Expected a truncated chunk error, got %v", pi.errors)
+	}
+	if _, ok := pi.trackToPluginMap["Lead"]; !ok {
+		t.Errorf("Expected the events before the truncation to be examined, got %v", pi.trackToPluginMap)
+	}
+}
+
+func TestReadVarint(t *testing.T) {
+	for _, test := range []struct {
+		bytes []byte
+		value int
+		fails bool
+	}{
+		{[]byte{0x05}, 5, false},
+		{[]byte{0x80, 0x01}, 128, false},
+		{[]byte{0xff, 0x7f}, 16383, false},
+		{[]byte{0x80}, 0, true},
+		{[]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, 0, true},
+	} {
+		_, value, err := readVarint(span{bytes: test.bytes})
+		if (err != nil) != test.fails || value != test.value {
+			t.Errorf("readVarint(% x) = %d, %v", test.bytes, value, err)
+		}
+	}
+}
+
+func FuzzExamineFLP(f *testing.F) {
+	content := syntheticFLP("20.8.4.2576", true)
+	f.Add(content)
+	f.Add(content[:len(content)/2])
+	f.Add(content[:10])
+	f.Add([]byte("FLhd"))
+	f.Add([]byte{})
+	f.Fuzz(func(t *testing.T, content []byte) {
+		if pi := examineFLPContent("fuzz.flp", content); pi == nil {
+			t.Fatal("examineFLPContent returned nil")
+		}
+	})
+}
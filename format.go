@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// A parser for a project file format. Each format registers its parser with [registerProjectFormat] when a package-level variable in the file that implements it is initialized, so that adding a format doesn't involve changing the code that finds and examines files.
+type projectParser interface {
+	// Returns the name of the format, such as "ALS".
+	Name() string
+	// Returns the extensions, including the leading dot, of files in the format.
+	Extensions() []string
+	// Check whether a file is in the format, given up to [sniffLength] bytes from its start, and the whole file for formats that need to look further.
+	Sniff(header []byte, content io.ReaderAt, size int64) bool
+	// Examine a file in the format. Returns nil if the file turns out not to be a project, which can happen for formats whose extension other applications use too.
+	Examine(path string) *projectInformation
+}
+
+// Identifies a project file format by the position of its parser in the registry.
+type projectFormat int
+
+// The format of files that no registered parser recognises.
+const PF_Unknown projectFormat = 0
+
+// The registered parsers, in the order in which they were registered. Formats are detected by trying each parser in this order.
+var projectParsers []projectParser
+
+// Register a parser, and return the format that identifies it.
+func registerProjectFormat(parser projectParser) projectFormat {
+	projectParsers = append(projectParsers, parser)
+	return projectFormat(len(projectParsers))
+}
+
+// Returns the parser for a project format, or nil if the format is unknown.
+func (pf projectFormat) parser() projectParser {
+	if pf < 1 || int(pf) > len(projectParsers) {
+		return nil
+	}
+	return projectParsers[pf-1]
+}
+
+// Returns the name of a project format.
+func (pf projectFormat) String() string {
+	if parser := pf.parser(); parser != nil {
+		return parser.Name()
+	}
+	return "unknown"
+}
+
+// Extensions that other applications use too. Files with these extensions are only examined if their content is recognised.
+var sharedExtensions = []string{bakExtension, trackArchiveExtension}
+
+// Returns the extensions of all the registered formats, which are the extensions included by default when traversing the hierarchy.
+func projectExtensions() []string {
+	extensions := []string{}
+	for _, parser := range projectParsers {
+		for _, extension := range parser.Extensions() {
+			if !slices.Contains(extensions, extension) {
+				extensions = append(extensions, extension)
+			}
+		}
+	}
+	return extensions
+}
+
+// Print the name and extensions of each registered format.
+func printProjectFormats(w io.Writer) {
+	for _, parser := range projectParsers {
+		fmt.Fprintf(w, "%s%s\n", padStringToWidth(parser.Name(), 12, ' '), strings.Join(parser.Extensions(), " "))
+	}
+}
+
+// The number of bytes read from the start of a file, or of its decompressed content, to detect its format.
+const sniffLength = 512
+
+// Returns the start of a text file, without any byte order marker or leading whitespace.
+func trimTextHeader(header []byte) []byte {
+	return bytes.TrimLeft(header, "\xef\xbb\xbf \t\r\n")
+}
+
+// Detect the format of a project file from its content rather than its name, by asking each registered parser in turn.
+func detectProjectFormat(path string) projectFormat {
+	file, size, err := openProjectFile(path)
+	if err != nil {
+		return PF_Unknown
+	}
+	defer file.Close()
+
+	header := make([]byte, sniffLength)
+	n, _ := io.ReadFull(file, header)
+	header = header[:n]
+
+	for index, parser := range projectParsers {
+		if parser.Sniff(header, file, size) {
+			return projectFormat(index + 1)
+		}
+	}
+	return PF_Unknown
+}
+
+// Choose the format of a project file from its content or, if the content isn't recognised, from its extension so that damaged projects are still reported.
+func chooseProjectFormat(path string) projectFormat {
+	if format := detectProjectFormat(path); format != PF_Unknown {
+		return format
+	}
+	extension := strings.ToLower(filepath.Ext(path))
+	if slices.Contains(sharedExtensions, extension) {
+		return PF_Unknown
+	}
+	for index, parser := range projectParsers {
+		if slices.Contains(parser.Extensions(), extension) {
+			return projectFormat(index + 1)
+		}
+	}
+	return PF_Unknown
+}
+
+// Choose the format of a project in Cubase's format based on the application that wrote it or, if that isn't known, its extension.
+func cubaseProjectFormat(pi *projectInformation) projectFormat {
+	if strings.Contains(strings.ToLower(pi.application), "nuendo") {
+		return PF_NPR
+	}
+	if len(pi.application) == 0 && strings.EqualFold(filepath.Ext(pi.path), nprExtension) {
+		return PF_NPR
+	}
+	return PF_CPR
+}
+
+// The name of the folder within which Cubase writes auto-saved copies of a project.
+const autoSavesFolderName = "Auto Saves"
+
+// Matches the name of a Cubase backup or auto-save, such as "Project-01.bak", capturing the name of the project.
+var cubaseBackupNamePattern = regexp.MustCompile(`(?i)^(.*)-\d+\.bak$`)
+
+// Check whether project [a] comes before project [b] when projects are listed by path, ignoring case, with backups grouped after the project that they are a copy of.
+func projectPathLess(a, b *projectInformation) bool {
+	aGroup, bGroup := a.path, b.path
+	if len(a.backupOf) != 0 {
+		aGroup = a.backupOf
+	}
+	if len(b.backupOf) != 0 {
+		bGroup = b.backupOf
+	}
+	if !strings.EqualFold(aGroup, bGroup) {
+		return strings.ToLower(aGroup) < strings.ToLower(bGroup)
+	}
+	// Within a group, the project itself comes first.
+	if aIsBackup, bIsBackup := len(a.backupOf) != 0, len(b.backupOf) != 0; aIsBackup != bIsBackup {
+		return bIsBackup
+	}
+	return strings.ToLower(a.path) < strings.ToLower(b.path)
+}
+
+// Find the Cubase or Nuendo project that a backup or auto-save is a copy of. Backups are written beside the project, and auto-saves are written to an Auto Saves folder beside it.
+// Returns an empty string if the file isn't a backup or its project doesn't exist.
+func findCubaseBackupParent(path string) string {
+	match := cubaseBackupNamePattern.FindStringSubmatch(filepath.Base(path))
+	if match == nil {
+		return ""
+	}
+
+	folder := projectFileDir(path)
+	if filepath.Base(folder) == autoSavesFolderName {
+		folder = projectFileDir(folder)
+	}
+
+	for _, extension := range []string{cprExtension, nprExtension} {
+		parent := joinProjectFilePath(folder, match[1]+extension)
+		if _, err := projectFileSize(parent); err == nil {
+			return parent
+		}
+	}
+	return ""
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// Create gzip-compressed XML content of the kind found in ALS files.
+func gzippedXML(t testing.TB, xml string) []byte {
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	if _, err := writer.Write([]byte(xml)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+// Write a file, creating its folder if necessary, and return its path.
+func writeTestFile(t testing.TB, path string, content []byte) string {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestChooseProjectFormat(t *testing.T) {
+	folder := t.TempDir()
+	als := gzippedXML(t, `<?xml version="1.0" encoding="UTF-8"?><Ableton MajorVersion="5" MinorVersion="11.0_11300"></Ableton>`)
+	cpr := syntheticCPR("Cubase", "Version 13.0.21")
+
+	tests := []struct {
+		name    string
+		content []byte
+		want    projectFormat
+	}{
+		{"Set.als", als, PF_ALS},
+		{"Project.cpr", cpr, PF_CPR},
+		{"Project-01.bak", cpr, PF_CPR},
+		{"Renamed.bak", als, PF_ALS},
+		{"Other.bak", []byte("not a project"), PF_Unknown},
+		{"Other.gz", gzippedXML(t, "<html></html>"), PF_Unknown},
+		{"Damaged.als", []byte("not gzip"), PF_ALS},
+		{"Damaged.cpr", []byte("RIFF"), PF_CPR},
+	}
+	for _, test := range tests {
+		path := writeTestFile(t, filepath.Join(folder, test.name), test.content)
+		if got := chooseProjectFormat(path); got != test.want {
+			t.Errorf("%s: expected %s, got %s", test.name, test.want, got)
+		}
+	}
+}
+
+func TestFindCubaseBackupParent(t *testing.T) {
+	folder := t.TempDir()
+	cpr := syntheticCPR("Cubase", "Version 13.0.21")
+	project := writeTestFile(t, filepath.Join(folder, "Song", "Song.cpr"), cpr)
+	backup := writeTestFile(t, filepath.Join(folder, "Song", "Song-01.bak"), cpr)
+	autoSave := writeTestFile(t, filepath.Join(folder, "Song", autoSavesFolderName, "Song-03.bak"), cpr)
+	orphan := writeTestFile(t, filepath.Join(folder, "Song", "Other-01.bak"), cpr)
+
+	if got := findCubaseBackupParent(backup); got != project {
+		t.Errorf("Expected the backup's parent to be %s, got %q", project, got)
+	}
+	if got := findCubaseBackupParent(autoSave); got != project {
+		t.Errorf("Expected the auto-save's parent to be %s, got %q", project, got)
+	}
+	if got := findCubaseBackupParent(orphan); got != "" {
+		t.Errorf("Expected no parent for a backup of a missing project, got %q", got)
+	}
+	if got := findCubaseBackupParent(project); got != "" {
+		t.Errorf("Expected no parent for a project, got %q", got)
+	}
+
+	if pi := examineProject(autoSave); pi == nil || pi.backupOf != project || pi.format != PF_CPR {
+		t.Errorf("Expected the auto-save to be examined as a backup of %s, got %+v", project, pi)
+	}
+}
+
+func TestProjectPathLess(t *testing.T) {
+	project := func(path, backupOf string) *projectInformation {
+		pi := newProjectInformation(path)
+		pi.backupOf = backupOf
+		return &pi
+	}
+	projects := []*projectInformation{
+		project("Song/Song-01.bak", "Song/Song.cpr"),
+		project("Zebra/Zebra.cpr", ""),
+		project("Song/Auto Saves/Song-03.bak", "Song/Song.cpr"),
+		project("song/Other.cpr", ""),
+		project("Song/Song.cpr", ""),
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		return projectPathLess(projects[i], projects[j])
+	})
+
+	paths := []string{}
+	for _, pi := range projects {
+		paths = append(paths, pi.path)
+	}
+	expected := []string{"song/Other.cpr", "Song/Song.cpr", "Song/Auto Saves/Song-03.bak", "Song/Song-01.bak", "Zebra/Zebra.cpr"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected backups to follow their project, got %q", paths)
+	}
+}
+
+// A parser for an in-house format, registered by the test to check that formats can be added without changing the code that finds and examines files.
+type testParser struct{}
+
+func (testParser) Name() string { return "Test" }
+
+func (testParser) Extensions() []string { return []string{".tst"} }
+
+func (testParser) Sniff(header []byte, content io.ReaderAt, size int64) bool {
+	return bytes.HasPrefix(header, []byte("TEST"))
+}
+
+func (testParser) Examine(path string) *projectInformation {
+	info := newProjectInformation(path)
+	info.mapTrackToPlugin("Plugin", "Track")
+	return &info
+}
+
+func TestProjectFormatRegistry(t *testing.T) {
+	for _, extension := range []string{alsExtension, cprExtension, nprExtension, bakExtension, songExtension} {
+		if !slices.Contains(projectExtensions(), extension) {
+			t.Errorf("Expected %s to be included by default", extension)
+		}
+	}
+
+	registered := len(projectParsers)
+	format := registerProjectFormat(testParser{})
+	t.Cleanup(func() { projectParsers = projectParsers[:registered] })
+
+	if format.String() != "Test" || !slices.Contains(projectExtensions(), ".tst") {
+		t.Errorf("Expected the registered format to be named and its extension included, got %s and %v", format, projectExtensions())
+	}
+
+	folder := t.TempDir()
+	pi := examineProject(writeTestFile(t, filepath.Join(folder, "Sniffed.dat"), []byte("TEST")))
+	if pi == nil || pi.format != format || !reflect.DeepEqual(pi.trackToPluginMap, map[string][]string{"Track": {"Plugin"}}) {
+		t.Errorf("Expected the registered parser to examine the file, got %+v", pi)
+	}
+	if got := chooseProjectFormat(writeTestFile(t, filepath.Join(folder, "Damaged.tst"), []byte("damaged"))); got != format {
+		t.Errorf("Expected the extension to choose the registered format, got %s", got)
+	}
+
+	var listing strings.Builder
+	printProjectFormats(&listing)
+	if !strings.Contains(listing.String(), "Test        .tst") {
+		t.Errorf("Expected the registered format to be listed, got:\n%s", listing.String())
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-isatty"
+)
+
+// The name of the folder within which Live writes timestamped copies of a set.
+const backupFolderName = "Backup"
+
+// The layout of the timestamp that Live adds to the names of backup copies.
+const backupTimestampLayout = "2006-01-02 150405"
+
+// Matches the name of a backup copy of a set, such as "Name [2024-03-01 101500].als", capturing the set name and the timestamp.
+var backupNamePattern = regexp.MustCompile(`^(.*) \[(\d{4}-\d{2}-\d{2} \d{6})\](\.[^.]+)$`)
+
+// A revision of a set: either a backup copy or the set itself.
+type projectRevision struct {
+	path      string
+	timestamp time.Time
+	current   bool // True for the set itself, which is always the latest revision.
+}
+
+// Describe when a revision was saved.
+func (pr projectRevision) label() string {
+	if pr.current {
+		return "current"
+	}
+	return pr.timestamp.Format("2006-01-02 15:04:05")
+}
+
+// Parse the name of a backup copy of a set. Returns the name of the set that it is a copy of, the time at which it was saved, and whether the name is that of a backup.
+func parseBackupName(basename string) (string, time.Time, bool) {
+	match := backupNamePattern.FindStringSubmatch(basename)
+	if match == nil {
+		return "", time.Time{}, false
+	}
+	timestamp, err := time.ParseInLocation(backupTimestampLayout, match[2], time.Local)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	return match[1] + match[3], timestamp, true
+}
+
+// Find the revisions of a set: the backup copies within its Backup folder, oldest first, followed by the set itself.
+func findRevisions(setPath string) []projectRevision {
+	revisions := []projectRevision{}
+
+	// Sets within archives have their Backup folder within the archive too.
+	backupFolder := joinProjectFilePath(projectFileDir(setPath), backupFolderName)
+	names, _ := listProjectFolder(backupFolder)
+	for _, basename := range names {
+		if name, timestamp, isBackup := parseBackupName(basename); isBackup && name == filepath.Base(setPath) {
+			revisions = append(revisions, projectRevision{path: joinProjectFilePath(backupFolder, basename), timestamp: timestamp})
+		}
+	}
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].timestamp.Before(revisions[j].timestamp)
+	})
+
+	return append(revisions, projectRevision{path: setPath, current: true})
+}
+
+// When a plugin first appeared in a set, and when it was last removed.
+type pluginTimeline struct {
+	plugin        string
+	firstAppeared *projectRevision
+	lastRemoved   *projectRevision // Nil if the plugin has never been removed.
+	inUse         bool             // True if the plugin is used by the latest revision.
+}
+
+// The plugin usage history of a set, built from its revisions.
+type setHistory struct {
+	path      string
+	revisions []projectRevision
+	skipped   []string // The revisions that could not be examined fully, and so were left out of the timelines.
+	timelines []pluginTimeline
+}
+
+// Build the plugin usage history of a set from its [revisions], oldest first, and the results of examining them.
+func newSetHistory(path string, revisions []projectRevision, results map[string]*projectInformation) setHistory {
+	sh := setHistory{path: path, revisions: revisions}
+
+	timelines := map[string]*pluginTimeline{}
+	previous := map[string][]string{}
+	for index := range revisions {
+		revision := &revisions[index]
+		pi := results[revision.path]
+		if pi == nil || pi.failed() {
+			sh.skipped = append(sh.skipped, revision.path)
+			continue
+		}
+
+		for plugin := range pi.pluginToTrackMap {
+			if _, found := timelines[plugin]; !found {
+				timelines[plugin] = &pluginTimeline{plugin: plugin, firstAppeared: revision}
+			}
+		}
+		for plugin := range previous {
+			if _, found := pi.pluginToTrackMap[plugin]; !found {
+				timelines[plugin].lastRemoved = revision
+			}
+		}
+		for _, timeline := range timelines {
+			_, timeline.inUse = pi.pluginToTrackMap[timeline.plugin]
+		}
+		previous = pi.pluginToTrackMap
+	}
+
+	iterateOverCISortedMap(timelines, func(_ string, timeline *pluginTimeline) {
+		sh.timelines = append(sh.timelines, *timeline)
+	})
+	return sh
+}
+
+// Generate a coloured description of the plugin usage history of a set.
+func (sh *setHistory) ColouredString(projectColour, keyColour, valueColour, errorColour, resetColour string) string {
+	var sb strings.Builder
+
+	sb.WriteString(projectColour + "Set: " + sh.path + "\n")
+	sb.WriteString(fmt.Sprintf("Revisions: %d, from %s to %s", len(sh.revisions), sh.revisions[0].label(), sh.revisions[len(sh.revisions)-1].label()) + resetColour + "\n\n")
+
+	maximumKeyWidth := 0
+	for _, timeline := range sh.timelines {
+		maximumKeyWidth = max(maximumKeyWidth, len(timeline.plugin))
+	}
+	keyWidth := max(32, maximumKeyWidth+3)
+
+	sb.WriteString("  " + padStringToWidth("Plugin", keyWidth, ' ') + padStringToWidth("First appeared", 21, ' ') + padStringToWidth("Last removed", 21, ' ') + "Status\n")
+	for _, timeline := range sh.timelines {
+		lastRemoved, status := "-", "in use"
+		if timeline.lastRemoved != nil {
+			lastRemoved = timeline.lastRemoved.label()
+		}
+		if !timeline.inUse {
+			status = "removed"
+		}
+		sb.WriteString("  " + keyColour + padStringToWidth(timeline.plugin, keyWidth, '.') + resetColour)
+		sb.WriteString(valueColour + padStringToWidth(timeline.firstAppeared.label(), 21, ' ') + padStringToWidth(lastRemoved, 21, ' ') + status + resetColour + "\n")
+	}
+	sb.WriteString("\n")
+
+	if len(sh.skipped) != 0 {
+		sb.WriteString(errorColour + "Revisions that could not be examined:" + resetColour + "\n")
+		for _, path := range sh.skipped {
+			sb.WriteString(" " + errorColour + path + resetColour + "\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// Generate a coloured or monochrome description of the history based on whether stdout is a terminal or a file.
+func (sh *setHistory) String() string {
+	isAtty := isatty.IsTerminal(os.Stdout.Fd())
+	if isAtty {
+		return sh.ColouredString(yellow, green, cyan, red, reset)
+	} else {
+		return sh.ColouredString("", "", "", "", "")
+	}
+}
+
+// Run the history command, which groups each Live set with the backup copies in its Backup folder and reports when each plugin first appeared and was last removed.
+func runHistory(args []string) int {
+	fs := newCommandFlagSet("history", "[flags] <file|folder> [<file|folder> ...]")
+	var options scanOptions
+	options.registerWorkerFlags(fs)
+	fs.Var(&options.foldersToIgnore, "ignore-folders", "A semicolon-separated list of folders to ignore when traversing the hierarchy. Backup folders are always examined alongside their sets.")
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return 2
+	}
+
+	// Backup folders are excluded from the search for sets, because their contents are found through the sets themselves.
+	options.extensions = []string{alsExtension}
+	options.foldersToIgnore = append(options.foldersToIgnore, backupFolderName)
+
+	sets := options.find(fs.Args())
+	slices.Sort(sets)
+
+	setRevisions := map[string][]projectRevision{}
+	revisionPaths := []string{}
+	for _, set := range sets {
+		setRevisions[set] = findRevisions(set)
+		for _, revision := range setRevisions[set] {
+			revisionPaths = append(revisionPaths, revision.path)
+		}
+	}
+
+	results := map[string]*projectInformation{}
+	exitCode := options.examine(revisionPaths, func(pi *projectInformation) {
+		results[pi.path] = pi
+	})
+
+	for _, set := range sets {
+		sh := newSetHistory(set, setRevisions[set], results)
+		fmt.Print(sh.String())
+	}
+	return exitCode
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseBackupName(t *testing.T) {
+	name, timestamp, isBackup := parseBackupName("My Set [2024-03-01 101500].als")
+	if !isBackup || name != "My Set.als" || !timestamp.Equal(time.Date(2024, 3, 1, 10, 15, 0, 0, time.Local)) {
+		t.Errorf("Unexpected result: %q %v %v", name, timestamp, isBackup)
+	}
+
+	for _, basename := range []string{"My Set.als", "My Set [2024-03-01].als", "My Set [2024-13-01 101500].als"} {
+		if _, _, isBackup := parseBackupName(basename); isBackup {
+			t.Errorf("Expected %q not to be recognised as a backup", basename)
+		}
+	}
+}
+
+func TestNewSetHistory(t *testing.T) {
+	revisions := []projectRevision{
+		{path: "Backup/Set [2024-03-01 101500].als", timestamp: time.Date(2024, 3, 1, 10, 15, 0, 0, time.Local)},
+		{path: "Backup/Set [2024-03-02 101500].als", timestamp: time.Date(2024, 3, 2, 10, 15, 0, 0, time.Local)},
+		{path: "Backup/Set [2024-03-03 101500].als", timestamp: time.Date(2024, 3, 3, 10, 15, 0, 0, time.Local)},
+		{path: "Set.als", current: true},
+	}
+	results := map[string]*projectInformation{
+		revisions[0].path: projectWithTracks(revisions[0].path, map[string][]string{"Bass": {"Old Compressor", "DUNE 3"}}),
+		revisions[1].path: projectWithTracks(revisions[1].path, map[string][]string{"Bass": {"DUNE 3"}}),
+		revisions[3].path: projectWithTracks(revisions[3].path, map[string][]string{"Bass": {"DUNE 3", "StandardCLIP"}}),
+	}
+
+	sh := newSetHistory("Set.als", revisions, results)
+
+	if len(sh.skipped) != 1 || sh.skipped[0] != revisions[2].path {
+		t.Errorf("Expected the missing revision to be skipped, got %v", sh.skipped)
+	}
+	if len(sh.timelines) != 3 {
+		t.Fatalf("Expected 3 timelines, got %v", sh.timelines)
+	}
+
+	expected := []struct {
+		plugin        string
+		firstAppeared string
+		lastRemoved   string
+		inUse         bool
+	}{
+		{"DUNE 3", revisions[0].path, "", true},
+		{"Old Compressor", revisions[0].path, revisions[1].path, false},
+		{"StandardCLIP", revisions[3].path, "", true},
+	}
+	for index, want := range expected {
+		got := sh.timelines[index]
+		lastRemoved := ""
+		if got.lastRemoved != nil {
+			lastRemoved = got.lastRemoved.path
+		}
+		if got.plugin != want.plugin || got.firstAppeared.path != want.firstAppeared || lastRemoved != want.lastRemoved || got.inUse != want.inUse {
+			t.Errorf("Expected %+v, got %s first appeared %s last removed %q in use %v", want, got.plugin, got.firstAppeared.path, lastRemoved, got.inUse)
+		}
+	}
+}
+
+func TestFindRevisions(t *testing.T) {
+	folder := t.TempDir()
+	t.Cleanup(closeArchives)
+	set := writeTestFile(t, filepath.Join(folder, "Set", "Set.als"), []byte("set"))
+	writeTestFile(t, filepath.Join(folder, "Set", backupFolderName, "Set [2024-03-02 101500].als"), []byte("backup"))
+	writeTestFile(t, filepath.Join(folder, "Set", backupFolderName, "Set [2024-03-01 101500].als"), []byte("backup"))
+	writeTestFile(t, filepath.Join(folder, "Set", backupFolderName, "Other [2024-03-01 101500].als"), []byte("other"))
+	archive := writeTestFile(t, filepath.Join(folder, "Finished.zip"), zipArchive(t,
+		"Set/Set.als", "set", "Set/Backup/Set [2024-03-01 101500].als", "backup"))
+
+	tests := []struct {
+		set      string
+		expected []string
+	}{
+		{set, []string{
+			filepath.Join(folder, "Set", backupFolderName, "Set [2024-03-01 101500].als"),
+			filepath.Join(folder, "Set", backupFolderName, "Set [2024-03-02 101500].als"),
+			set,
+		}},
+		// Sets within archives have their backups found within the archive.
+		{archive + "!/Set/Set.als", []string{archive + "!/Set/Backup/Set [2024-03-01 101500].als", archive + "!/Set/Set.als"}},
+	}
+	for _, test := range tests {
+		paths := []string{}
+		for _, revision := range findRevisions(test.set) {
+			paths = append(paths, revision.path)
+		}
+		if !slices.Equal(paths, test.expected) {
+			t.Errorf("%s: expected %q, got %q", test.set, test.expected, paths)
+		}
+	}
+}
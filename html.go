@@ -0,0 +1,172 @@
+package main
+
+import (
+	"html/template"
+	"io"
+	"strings"
+)
+
+// The HTML report is a single document with inline styles and scripts, so that it can be emailed or opened without a network connection.
+// Every table can be sorted by clicking on a column heading, and filtered by typing into the box above it.
+var htmlReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"join": strings.Join,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Plugin report</title>
+<style>
+body { font-family: system-ui, -apple-system, "Segoe UI", sans-serif; margin: 2em; color: #222; }
+h1, h2, h3 { font-weight: 600; }
+h2 { margin-top: 2em; border-bottom: 1px solid #ccc; padding-bottom: 0.2em; }
+table { border-collapse: collapse; margin: 0.5em 0 1.5em; width: 100%; }
+th, td { border: 1px solid #ddd; padding: 0.3em 0.6em; text-align: left; vertical-align: top; }
+th { background: #f3f3f3; cursor: pointer; user-select: none; white-space: nowrap; }
+th[aria-sort=ascending]::after { content: " \25B2"; }
+th[aria-sort=descending]::after { content: " \25BC"; }
+tr:nth-child(even) td { background: #fafafa; }
+td.number { text-align: right; }
+input.filter { padding: 0.3em; width: 20em; }
+dl { display: grid; grid-template-columns: max-content auto; gap: 0.2em 1em; }
+dt { font-weight: 600; }
+dd { margin: 0; }
+.error { color: #b00020; }
+</style>
+</head>
+<body>
+<h1>Plugin report</h1>
+<p>{{.ProjectCount}} project(s), {{len .Plugins}} plugin(s).</p>
+{{- if .Projects}}
+
+<h2>Projects</h2>
+<input class="filter" type="search" placeholder="Filter" data-table="projects">
+<table id="projects" class="sortable">
+<thead><tr><th>Project</th><th>Format</th><th>Version</th><th>Plugins</th><th>Tracks</th><th>Errors</th></tr></thead>
+<tbody>
+{{- range .Projects}}
+<tr><td><a href="#project-{{.ID}}">{{.Path}}</a></td><td>{{.Format}}</td><td>{{.Version}}</td><td class="number">{{len .Plugins}}</td><td class="number">{{len .Tracks}}</td><td class="number">{{len .Errors}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+{{- if .Summary}}
+
+<h2>Library</h2>
+<input class="filter" type="search" placeholder="Filter" data-table="library">
+<table id="library" class="sortable">
+<thead><tr><th>Plugin</th><th>Projects</th><th>Tracks</th><th>Used in</th></tr></thead>
+<tbody>
+{{- range .Plugins}}
+<tr><td>{{.Name}}</td><td class="number">{{len .Projects}}</td><td class="number">{{.TrackCount}}</td><td>{{join .Projects ", "}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+{{- range .Projects}}
+
+<h2 id="project-{{.ID}}">{{.Path}}</h2>
+<dl>
+{{- if .BackupOf}}
+<dt>Backup of</dt><dd>{{.BackupOf}}</dd>
+{{- end}}
+{{- if .Application}}
+<dt>Application</dt><dd>{{.Application}}</dd>
+{{- end}}
+<dt>Version</dt><dd>{{.Version}}</dd>
+{{- range .Metadata}}
+<dt>{{.Name}}</dt><dd>{{.Value}}</dd>
+{{- end}}
+</dl>
+
+<h3>Plugins</h3>
+<input class="filter" type="search" placeholder="Filter" data-table="project-{{.ID}}-plugins">
+<table id="project-{{.ID}}-plugins" class="sortable">
+<thead><tr><th>Plugin</th><th>Tracks</th></tr></thead>
+<tbody>
+{{- range .Plugins}}
+<tr><td>{{.Key}}</td><td>{{join .Values ", "}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+
+<h3>Tracks</h3>
+<input class="filter" type="search" placeholder="Filter" data-table="project-{{.ID}}-tracks">
+<table id="project-{{.ID}}-tracks" class="sortable">
+<thead><tr><th>Track</th><th>Plugins</th></tr></thead>
+<tbody>
+{{- range .Tracks}}
+<tr><td>{{.Key}}</td><td>{{join .Values ", "}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- if .MissingMedia}}
+
+<h3 class="error">Missing media files</h3>
+<ul>
+{{- range .MissingMedia}}
+<li class="error">{{.}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .Warnings}}
+
+<h3>Warnings</h3>
+<ul>
+{{- range .Warnings}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .Errors}}
+
+<h3 class="error">Errors</h3>
+<ul>
+{{- range .Errors}}
+<li class="error">{{.}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- end}}
+
+<script>
+// Sort a table by the column whose heading was clicked, numerically if every value in the column is a number. Clicking again reverses the order.
+document.querySelectorAll("table.sortable th").forEach(function (heading) {
+	heading.addEventListener("click", function () {
+		var table = heading.closest("table");
+		var body = table.tBodies[0];
+		var column = heading.cellIndex;
+		var ascending = heading.getAttribute("aria-sort") !== "ascending";
+		table.querySelectorAll("th").forEach(function (other) { other.removeAttribute("aria-sort"); });
+		heading.setAttribute("aria-sort", ascending ? "ascending" : "descending");
+
+		var rows = Array.prototype.slice.call(body.rows);
+		var numeric = rows.every(function (row) { var text = row.cells[column].textContent; return text !== "" && !isNaN(text); });
+		rows.sort(function (a, b) {
+			var x = a.cells[column].textContent, y = b.cells[column].textContent;
+			var order = numeric ? x - y : x.localeCompare(y, undefined, { sensitivity: "base", numeric: true });
+			return ascending ? order : -order;
+		});
+		rows.forEach(function (row) { body.appendChild(row); });
+	});
+});
+
+// Hide the rows of a table that don't contain the text typed into its filter box, ignoring case.
+document.querySelectorAll("input.filter").forEach(function (input) {
+	input.addEventListener("input", function () {
+		var text = input.value.toLowerCase();
+		var table = document.getElementById(input.getAttribute("data-table"));
+		Array.prototype.forEach.call(table.tBodies[0].rows, function (row) {
+			row.hidden = row.textContent.toLowerCase().indexOf(text) < 0;
+		});
+	});
+});
+</script>
+</body>
+</html>
+`))
+
+// Write the results of a scan as a self-contained HTML document.
+func writeHTMLReport(w io.Writer, r report) error {
+	return htmlReportTemplate.Execute(w, r)
+}
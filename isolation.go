@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Examine a project with [examine], isolating the caller from any panic that it raises and abandoning it if it runs for longer than [timeout].
+// A [timeout] of zero or less disables the time limit. Panics and timeouts are logged as errors against the returned projectInformation.
+func examineIsolated(path string, timeout time.Duration, examine func(string) *projectInformation) *projectInformation {
+	// Buffered so that an abandoned examination can still deliver its result and exit.
+	done := make(chan *projectInformation, 1)
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				info := newProjectInformation(path)
+				info.logError(newProjectError(EK_Panic, fmt.Errorf("recovered from panic: %v", r)))
+				done <- &info
+			}
+		}()
+		done <- examine(path)
+	}()
+
+	started := time.Now()
+	pi := awaitExamination(path, timeout, done)
+	if pi != nil {
+		pi.elapsed = time.Since(started)
+	}
+	return pi
+}
+
+// Wait for an examination to deliver its result on [done], or for [timeout] to expire.
+func awaitExamination(path string, timeout time.Duration, done <-chan *projectInformation) *projectInformation {
+	if timeout <= 0 {
+		return <-done
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case pi := <-done:
+		return pi
+	case <-timer.C:
+		info := newProjectInformation(path)
+		info.logError(newProjectError(EK_Timeout, fmt.Errorf("gave up after %s", timeout)))
+		return &info
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExamineIsolated(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		pi := examineIsolated("ok.als", time.Second, func(path string) *projectInformation {
+			info := newProjectInformation(path)
+			info.mapTrackToPlugin("DUNE 3", "Bass")
+			return &info
+		})
+		if len(pi.errors) != 0 || len(pi.pluginToTrackMap["DUNE 3"]) != 1 {
+			t.Errorf("Unexpected result: %+v", pi)
+		}
+	})
+
+	t.Run("Panic", func(t *testing.T) {
+		pi := examineIsolated("bad.cpr", time.Second, func(path string) *projectInformation {
+			var b []byte
+			_ = b[10]
+			return nil
+		})
+		if pi == nil || pi.path != "bad.cpr" || len(pi.errors) != 1 || pi.errors[0].kind != EK_Panic {
+			t.Errorf("Expected a recovered panic, got %+v", pi)
+		}
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+		pi := examineIsolated("slow.als", 10*time.Millisecond, func(path string) *projectInformation {
+			<-release
+			return nil
+		})
+		if pi == nil || len(pi.errors) != 1 || pi.errors[0].kind != EK_Timeout {
+			t.Errorf("Expected a timeout, got %+v", pi)
+		}
+	})
+}
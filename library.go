@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+)
+
+// Information aggregated across all of the projects in a library.
+type libraryInformation struct {
+	projectCount       int
+	pluginToProjectMap map[string][]string
+	pluginTrackCounts  map[string]int
+}
+
+// Create and initialize a new libraryInformation instance.
+func newLibraryInformation() libraryInformation {
+	return libraryInformation{
+		pluginToProjectMap: map[string][]string{},
+		pluginTrackCounts:  map[string]int{},
+	}
+}
+
+// Add the plugins used by a project to the library.
+func (li *libraryInformation) addProject(pi *projectInformation) {
+	li.projectCount++
+	for plugin, tracks := range pi.pluginToTrackMap {
+		li.pluginToProjectMap[plugin] = append(li.pluginToProjectMap[plugin], pi.path)
+		li.pluginTrackCounts[plugin] += len(sortedCIUnique(tracks))
+	}
+}
+
+// Generate a coloured description of the library, listing the projects within which each plugin appears.
+func (li *libraryInformation) ColouredString(headingColour, keyColour, valueColour, resetColour string) string {
+	var sb strings.Builder
+
+	sb.WriteString(headingColour + fmt.Sprintf("Library: %d projects, %d plugins", li.projectCount, len(li.pluginToProjectMap)) + resetColour + "\n\n")
+	sb.WriteString("Plugin followed by a list of the projects within which it appears:\n")
+
+	maximumKeyWidth := calculateMaximumKeyWidth(li.pluginToProjectMap)
+
+	iterateOverCISortedMap(li.pluginToProjectMap, func(plugin string, projects []string) {
+		sb.WriteString("  " + keyColour + padStringToWidth(plugin, max(32, maximumKeyWidth+3), '.') + resetColour)
+		sort.Slice(projects, func(i, j int) bool {
+			return strings.ToLower(projects[i]) < strings.ToLower(projects[j])
+		})
+		projects = dedup(projects)
+		sb.WriteString(fmt.Sprintf("%d project(s)\n", len(projects)))
+		for _, project := range projects {
+			sb.WriteString("    " + valueColour + project + resetColour + "\n")
+		}
+	})
+
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// Generate a coloured or monochrome description for a library based on whether stdout is a terminal or a file.
+func (li *libraryInformation) String() string {
+	isAtty := isatty.IsTerminal(os.Stdout.Fd())
+	if isAtty {
+		return li.ColouredString(yellow, green, cyan, reset)
+	} else {
+		return li.ColouredString("", "", "", "")
+	}
+}
+
+// Generate a tab-separated inventory of the plugins in the library, with the number of projects and tracks that use each.
+func (li *libraryInformation) inventory() string {
+	var sb strings.Builder
+
+	sb.WriteString("Plugin\tProjects\tTracks\n")
+	iterateOverCISortedMap(li.pluginToProjectMap, func(plugin string, projects []string) {
+		sb.WriteString(fmt.Sprintf("%s\t%d\t%d\n", plugin, len(projects), li.pluginTrackCounts[plugin]))
+	})
+	return sb.String()
+}
+
+// Run the report command, which prints the projects within which each plugin appears across the whole library.
+func runReport(args []string) int {
+	fs := newCommandFlagSet("report", "[flags] <file|folder> [<file|folder> ...]")
+	var options scanOptions
+	options.register(fs)
+	var formatOptions outputOptions
+	formatOptions.register(fs)
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return 2
+	}
+
+	if formatOptions.format != OF_Text || len(formatOptions.templatePath) != 0 {
+		output, err := newReportOutput(formatOptions)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		output.libraryOnly = true
+		return output.finishCommand(options.scan(fs.Args(), output.add))
+	}
+
+	library := newLibraryInformation()
+	exitCode := options.scan(fs.Args(), library.addProject)
+	fmt.Print(library.String())
+	return exitCode
+}
+
+// Run the inventory command, which prints a tab-separated list of the plugins used across the whole library.
+func runInventory(args []string) int {
+	fs := newCommandFlagSet("inventory", "[flags] <file|folder> [<file|folder> ...]")
+	var options scanOptions
+	options.register(fs)
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return 2
+	}
+
+	library := newLibraryInformation()
+	exitCode := options.scan(fs.Args(), library.addProject)
+	fmt.Print(library.inventory())
+	return exitCode
+}
@@ -4,17 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
-	"path/filepath"
-	"runtime"
-	"slices"
 	"strings"
-
-	"github.com/MrSplidge/go-coutil"
-)
-
-const (
-	alsExtension = ".als"
-	cprExtension = ".cpr"
 )
 
 type stringFlags []string
@@ -30,59 +20,95 @@ func (sf *stringFlags) Set(value string) error {
 	return nil
 }
 
-// Main entry point for the program.
-func main() {
-	var numThreadsFlag = flag.Int("num-threads", runtime.NumCPU(), "The number of worker threads to use.")
+// A subcommand of the program. The [run] function is passed the arguments that follow the command name, and returns the process exit code.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) int
+}
+
+// The command used when the first argument doesn't name a command, so that existing invocations keep working.
+const defaultCommandName = "scan"
 
-	var foldersToIgnore stringFlags
-	flag.Var(&foldersToIgnore, "ignore-folders", "A semicolon-separated list of folders to ignore when traversing the hierarchy.")
+// Returns the available commands.
+func allCommands() []command {
+	return []command{
+		{name: "scan", summary: "Examine projects and print the plugins used on each track, and vice versa (default).", run: runScan},
+		{name: "report", summary: "Print the projects within which each plugin appears, across the whole library.", run: runReport},
+		{name: "query", summary: "Examine projects and print only the plugins and tracks that match the given filters.", run: runQuery},
+		{name: "history", summary: "Report when each plugin first appeared in, and was last removed from, each Live set, using its Backup folder.", run: runHistory},
+		{name: "diff", summary: "Compare the tracks and plugins of two projects.", run: runDiff},
+		{name: "inventory", summary: "Print a tab-separated list of plugins with project and track counts.", run: runInventory},
+		{name: "media", summary: "Print the media files, such as samples, that each project refers to, and flag the missing ones.", run: runMedia},
+		{name: "serve", summary: "Examine projects, keep the results up to date, and browse them, or query them with a JSON API, over HTTP.", run: runServe},
+		{name: "dump", summary: "Print the internal structure of a project file.", run: runDump},
+	}
+}
+
+// Find a command by name within [commands]. Returns nil if there is no such command.
+func findCommand(commands []command, name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return &cmd
+		}
+	}
+	return nil
+}
 
-	var extensions stringFlags
-	flag.Var(&extensions, "extensions", "A semicolon-separated list of project file extensions to include when traversing the hierarchy (default .als;.cpr).")
+// Print the top-level usage message, listing [commands].
+func printUsage(commands []command) {
+	fmt.Fprintf(os.Stderr, "go-plugins [<command>] [flags] <file|folder> [<file|folder> ...]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %s%s\n", padStringToWidth(cmd.name, 12, ' '), cmd.summary)
+	}
+	fmt.Fprintf(os.Stderr, "\nUse \"go-plugins help <command>\" or \"go-plugins <command> -h\" for a command's flags.\n")
+}
 
-	flag.Parse()
+// Print the top-level usage message, followed by the flags of the default command, which is run when no command is given. Returns the default command's exit code.
+func printHelp(commands []command) int {
+	printUsage(commands)
+	fmt.Fprintf(os.Stderr, "\nWithout a command, %s is run:\n\n", defaultCommandName)
+	return findCommand(commands, defaultCommandName).run([]string{"-h"})
+}
 
-	if flag.NArg() < 1 {
-		fmt.Fprintf(os.Stderr, "go-plugins [-num-threads <n>] [-ignore-folders folder[;folder;...]] [-extensions extension[;extension;...]] <file|folder> [<file|folder> ...]\n\n")
-		flag.PrintDefaults()
-		return
+// Create a flag set for a command, with a usage message that shows the command's [synopsis] and flags.
+func newCommandFlagSet(name, synopsis string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "go-plugins %s %s\n\n", name, synopsis)
+		fs.PrintDefaults()
 	}
+	return fs
+}
 
-	if len(extensions) == 0 {
-		extensions = []string{alsExtension, cprExtension}
+// Run the command named by the first of [args], passing it the remaining arguments. If the first argument isn't the name of a command, the default command is run with all of the arguments.
+// So that existing invocations keep working, a first argument that names an existing file or folder is treated as a path even if it is also the name of a command.
+// Returns the process exit code.
+func dispatch(commands []command, args []string) int {
+	if len(args) == 0 {
+		return printHelp(commands)
 	}
 
-	fmt.Printf("Using %d threads.\n", *numThreadsFlag)
-	fmt.Println("Ignoring these folders:", foldersToIgnore)
-	fmt.Println("Scanning these items:", flag.Args())
-	fmt.Println()
-
-	coutil.WorkPool(
-		*numThreadsFlag,
-		// Work items to process.
-		scanAndFilterPaths(flag.Args(),
-			// Exclude certain folders.
-			func(basename, fullPath string) bool {
-				return !slices.Contains(foldersToIgnore, basename)
-			},
-			// Include files with certain file extensions.
-			func(basename, fullPath string) bool {
-				return slices.Contains(extensions, filepath.Ext(basename))
-			}),
-		// Work item processor.
-		func(path string) *projectInformation {
-			switch filepath.Ext(path) {
-			case alsExtension:
-				return examineALS(path)
-			case cprExtension:
-				return examineCPR(path)
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		if len(args) > 1 {
+			if cmd := findCommand(commands, args[1]); cmd != nil {
+				return cmd.run([]string{"-h"})
 			}
-			return nil
-		},
-		// Results processor.
-		func(pi *projectInformation) {
-			if pi != nil {
-				fmt.Print(pi.String())
-			}
-		})
+		}
+		return printHelp(commands)
+	}
+
+	if cmd := findCommand(commands, args[0]); cmd != nil {
+		if _, err := os.Stat(args[0]); err != nil {
+			return cmd.run(args[1:])
+		}
+	}
+
+	return findCommand(commands, defaultCommandName).run(args)
+}
+
+// Main entry point for the program.
+func main() {
+	os.Exit(dispatch(allCommands(), os.Args[1:]))
 }
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDispatch(t *testing.T) {
+	var ran string
+	var received []string
+	newCommand := func(name string, exitCode int) command {
+		return command{name: name, run: func(args []string) int {
+			ran, received = name, args
+			return exitCode
+		}}
+	}
+	commands := []command{newCommand("scan", 3), newCommand("report", 4), newCommand("media", 5)}
+
+	// Run in a folder that contains a folder named after a command.
+	folder := t.TempDir()
+	if err := os.Mkdir(filepath.Join(folder, "report"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(folder); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(previous) })
+
+	tests := []struct {
+		args     []string
+		ran      string
+		received []string
+		exitCode int
+	}{
+		{[]string{"media", "-missing", "Sets"}, "media", []string{"-missing", "Sets"}, 5},
+		{[]string{"scan", "Sets"}, "scan", []string{"Sets"}, 3},
+		// Existing invocations without a command run the default command with all of the arguments.
+		{[]string{"-ignore-folders", "Backup", "Sets"}, "scan", []string{"-ignore-folders", "Backup", "Sets"}, 3},
+		{[]string{"Sets"}, "scan", []string{"Sets"}, 3},
+		{[]string{"help", "report"}, "report", []string{"-h"}, 4},
+		// The top-level help includes the flags of the default command.
+		{[]string{"help", "unknown"}, "scan", []string{"-h"}, 3},
+		{[]string{"-h"}, "scan", []string{"-h"}, 3},
+		{[]string{}, "scan", []string{"-h"}, 3},
+		// A first argument that names an existing file or folder is a path, even if it is also the name of a command.
+		{[]string{"report", "Sets"}, "scan", []string{"report", "Sets"}, 3},
+	}
+	for _, test := range tests {
+		ran, received = "", nil
+		exitCode := dispatch(commands, test.args)
+		if ran != test.ran || !reflect.DeepEqual(received, test.received) || exitCode != test.exitCode {
+			t.Errorf("%q: expected %q to run with %q and exit with %d, got %q with %q and %d", test.args, test.ran, test.received, test.exitCode, ran, received, exitCode)
+		}
+	}
+}
+
+func TestAllCommands(t *testing.T) {
+	names := map[string]bool{}
+	for _, cmd := range allCommands() {
+		if names[cmd.name] {
+			t.Errorf("The command %q is listed more than once", cmd.name)
+		}
+		names[cmd.name] = true
+		if cmd.run == nil || len(cmd.summary) == 0 {
+			t.Errorf("The command %q has no summary or function", cmd.name)
+		}
+	}
+	if !names[defaultCommandName] {
+		t.Errorf("The default command %q isn't listed", defaultCommandName)
+	}
+}
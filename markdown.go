@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// The characters that are escaped in Markdown text so that names and paths are shown as they are, rather than as formatting, links or table columns.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`, `<`, `\<`, `>`, `\>`, `#`, `\#`, `|`, `\|`,
+	"\r\n", " ", "\n", " ",
+)
+
+// Escape text for use in a Markdown heading, list item or table cell.
+func escapeMarkdown(text string) string {
+	return markdownEscaper.Replace(text)
+}
+
+// Write a Markdown table with the given column headings. Each row must have one cell for each heading.
+func writeMarkdownTable(sb *strings.Builder, headings []string, rows [][]string) {
+	sb.WriteString("| " + strings.Join(headings, " | ") + " |\n")
+	sb.WriteString("|" + strings.Repeat(" --- |", len(headings)) + "\n")
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for index, cell := range row {
+			cells[index] = escapeMarkdown(cell)
+		}
+		sb.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+	sb.WriteString("\n")
+}
+
+// Convert report rows to the cells of a two-column Markdown table.
+func markdownRowCells(rows []reportRow) [][]string {
+	cells := [][]string{}
+	for _, row := range rows {
+		cells = append(cells, []string{row.Key, strings.Join(row.Values, ", ")})
+	}
+	return cells
+}
+
+// Write the results of a scan as a Markdown document. Each project is a section with its version and metadata in a list, and the plugins used on each track, and vice versa, in tables.
+// The summary of the library, if the report includes it, follows the projects.
+func writeMarkdownReport(w io.Writer, r report) error {
+	var sb strings.Builder
+
+	sb.WriteString("# Plugin report\n\n")
+	sb.WriteString(fmt.Sprintf("%d project(s), %d plugin(s).\n\n", r.ProjectCount, len(r.Plugins)))
+
+	for _, project := range r.Projects {
+		sb.WriteString("## " + escapeMarkdown(project.Path) + "\n\n")
+		if len(project.BackupOf) != 0 {
+			sb.WriteString("- **Backup of:** " + escapeMarkdown(project.BackupOf) + "\n")
+		}
+		if len(project.Application) != 0 {
+			sb.WriteString("- **Application:** " + escapeMarkdown(project.Application) + "\n")
+		}
+		sb.WriteString("- **Version:** " + escapeMarkdown(project.Version) + "\n")
+		for _, field := range project.Metadata {
+			sb.WriteString("- **" + field.Name + ":** " + escapeMarkdown(field.Value) + "\n")
+		}
+		sb.WriteString("\n")
+
+		sb.WriteString("### Plugins\n\n")
+		writeMarkdownTable(&sb, []string{"Plugin", "Tracks"}, markdownRowCells(project.Plugins))
+		sb.WriteString("### Tracks\n\n")
+		writeMarkdownTable(&sb, []string{"Track", "Plugins"}, markdownRowCells(project.Tracks))
+
+		for _, section := range []struct {
+			heading string
+			items   []string
+		}{{"Missing media files", project.MissingMedia}, {"Warnings", project.Warnings}, {"Errors", project.Errors}} {
+			if len(section.items) != 0 {
+				sb.WriteString("### " + section.heading + "\n\n")
+				for _, item := range section.items {
+					sb.WriteString("- " + escapeMarkdown(item) + "\n")
+				}
+				sb.WriteString("\n")
+			}
+		}
+	}
+
+	if r.Summary {
+		sb.WriteString("## Library\n\n")
+		rows := [][]string{}
+		for _, plugin := range r.Plugins {
+			rows = append(rows, []string{plugin.Name, fmt.Sprint(len(plugin.Projects)), fmt.Sprint(plugin.TrackCount), strings.Join(plugin.Projects, ", ")})
+		}
+		writeMarkdownTable(&sb, []string{"Plugin", "Projects", "Tracks", "Used in"}, rows)
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// A predicate that checks whether a piece of text matches a pattern.
+type textMatcher func(text string) bool
+
+// Create a case-insensitive textMatcher from a pattern.
+// A pattern of the form /expression/ is a regular expression that may match any part of the text.
+// A pattern that contains any of the glob metacharacters *, ? or [ must match the whole text.
+// Any other pattern matches text that contains it.
+func newTextMatcher(pattern string) (textMatcher, error) {
+	if len(pattern) >= 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile("(?i)" + pattern[1:len(pattern)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %s: %w", pattern, err)
+		}
+		return re.MatchString, nil
+	}
+
+	if strings.ContainsAny(pattern, "*?[") {
+		re, err := regexp.Compile("(?i)^" + globToRegexp(pattern) + "$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob %s: %w", pattern, err)
+		}
+		return re.MatchString, nil
+	}
+
+	lowerPattern := strings.ToLower(pattern)
+	return func(text string) bool {
+		return strings.Contains(strings.ToLower(text), lowerPattern)
+	}, nil
+}
+
+// Translate a glob pattern into the equivalent regular expression. Character classes are passed through unchanged, except that [!...] becomes [^...].
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+	inClass := false
+	for index, ch := range glob {
+		switch {
+		case inClass:
+			if ch == ']' {
+				inClass = false
+			}
+			if ch == '!' && glob[index-1] == '[' {
+				ch = '^'
+			}
+			sb.WriteRune(ch)
+		case ch == '*':
+			sb.WriteString(".*")
+		case ch == '?':
+			sb.WriteString(".")
+		case ch == '[':
+			inClass = true
+			sb.WriteRune(ch)
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	return sb.String()
+}
+
+// A textMatcher that matches any text.
+func matchAnything(string) bool {
+	return true
+}
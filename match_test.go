@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestNewTextMatcher(t *testing.T) {
+	tests := []struct {
+		pattern string
+		text    string
+		want    bool
+	}{
+		{"bass", "5 D3.RBass1", true},
+		{"bass", "Pad", false},
+		{"DUNE 3", "dune 3", true},
+		{"*Bass*", "Sub BASS", true},
+		{"Bass*", "Sub Bass", false},
+		{"VPS Avenger?x64", "VPS Avenger_x64", true},
+		{"[0-9]* Kick", "4 Kick", true},
+		{"/^valhalla/", "ValhallaShimmer", true},
+		{"/^valhalla/", "Blackhole", false},
+		{"[!0-9]*", "Master", true},
+		{"[!0-9]*", "4 Kick", false},
+		{"1+1", "1+1=2", true},
+	}
+	for _, test := range tests {
+		matcher, err := newTextMatcher(test.pattern)
+		if err != nil {
+			t.Errorf("newTextMatcher(%q) failed: %v", test.pattern, err)
+			continue
+		}
+		if got := matcher(test.text); got != test.want {
+			t.Errorf("Pattern %q against %q: expected %v, got %v", test.pattern, test.text, test.want, got)
+		}
+	}
+
+	if _, err := newTextMatcher("/(/"); err == nil {
+		t.Errorf("Expected an error for an invalid regular expression")
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+)
+
+// A reference from a project to an external media file, such as an audio sample.
+type mediaReference struct {
+	path    string // The path at which the file was found or, if it is missing, the path recorded in the project.
+	missing bool
+}
+
+// Record a reference to a media file, and check whether the file exists. [recordedPath] is the path recorded in the project, which is usually absolute, and [relativePath] is a path relative to the project's folder, if one is known.
+// The file is looked for relative to the project first, so that projects that have been moved along with their media are resolved, and then at its recorded path. Paths may use either kind of separator.
+func (pi *projectInformation) addMediaReference(recordedPath, relativePath string) {
+	candidates := []string{}
+	if len(relativePath) != 0 {
+		// Backslashes are replaced explicitly, because filepath.ToSlash only replaces the separator of the operating system that the tool is running on.
+		candidates = append(candidates, joinProjectFilePath(projectFileDir(pi.path), strings.ReplaceAll(relativePath, `\`, "/")))
+	}
+	if len(recordedPath) != 0 {
+		candidates = append(candidates, recordedPath)
+	}
+	if len(candidates) == 0 {
+		return
+	}
+
+	reference := mediaReference{path: candidates[len(candidates)-1], missing: true}
+	for _, candidate := range candidates {
+		if _, err := projectFileSize(candidate); err == nil {
+			reference = mediaReference{path: candidate}
+			break
+		}
+	}
+
+	for _, existing := range pi.mediaFiles {
+		if existing.path == reference.path {
+			return
+		}
+	}
+	pi.mediaFiles = append(pi.mediaFiles, reference)
+}
+
+// Return the media files that a project refers to, sorted by path.
+func (pi *projectInformation) sortedMediaFiles() []mediaReference {
+	files := append([]mediaReference{}, pi.mediaFiles...)
+	sort.Slice(files, func(i, j int) bool {
+		return strings.ToLower(files[i].path) < strings.ToLower(files[j].path)
+	})
+	return files
+}
+
+// Return the number of media files that a project refers to that couldn't be found.
+func (pi *projectInformation) missingMediaCount() int {
+	count := 0
+	for _, file := range pi.mediaFiles {
+		if file.missing {
+			count++
+		}
+	}
+	return count
+}
+
+// Generate a coloured list of the media files that a project refers to. If [missingOnly] is true, only the files that couldn't be found are listed.
+func (pi *projectInformation) mediaColouredString(projectColour, valueColour, missingColour, resetColour string, missingOnly bool) string {
+	var sb strings.Builder
+
+	sb.WriteString(projectColour + "Project: " + pi.path + resetColour + "\n")
+	sb.WriteString(fmt.Sprintf("Media files: %d, missing: %d\n", len(pi.mediaFiles), pi.missingMediaCount()))
+	for _, file := range pi.sortedMediaFiles() {
+		if file.missing {
+			sb.WriteString("  " + missingColour + "missing " + file.path + resetColour + "\n")
+		} else if !missingOnly {
+			sb.WriteString("  " + valueColour + "found   " + file.path + resetColour + "\n")
+		}
+	}
+	sb.WriteString("\n")
+
+	return sb.String()
+}
+
+// Generate a coloured or monochrome list of the media files that a project refers to, based on whether stdout is a terminal or a file.
+func (pi *projectInformation) mediaString(missingOnly bool) string {
+	if isatty.IsTerminal(os.Stdout.Fd()) {
+		return pi.mediaColouredString(yellow, cyan, red, reset, missingOnly)
+	}
+	return pi.mediaColouredString("", "", "", "", missingOnly)
+}
+
+// Run the media command, which prints the media files that each project refers to and whether they could be found.
+// The exit code is 1 if any media file is missing, so that scripts can check that projects will open with all their media.
+func runMedia(args []string) int {
+	fs := newCommandFlagSet("media", "[flags] <file|folder> [<file|folder> ...]")
+	var options scanOptions
+	options.register(fs)
+	var missingFlag = fs.Bool("missing", false, "Only list the projects that refer to missing media files, and only those files.")
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return 2
+	}
+
+	missing := 0
+	exitCode := options.scan(fs.Args(), func(pi *projectInformation) {
+		missing += pi.missingMediaCount()
+		if !*missingFlag || pi.missingMediaCount() != 0 {
+			fmt.Print(pi.mediaString(*missingFlag))
+		}
+	})
+
+	if missing != 0 {
+		fmt.Fprintf(os.Stderr, "%d media file(s) are missing.\n", missing)
+		exitCode = 1
+	}
+	return exitCode
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testALSSamples = `<?xml version="1.0" encoding="UTF-8"?>
+<Ableton MajorVersion="5" MinorVersion="11.0_11300">
+	<LiveSet>
+		<Tracks>
+			<AudioTrack>
+				<SampleRef>
+					<FileRef>
+						<RelativePathType Value="3"/>
+						<RelativePath Value="Samples/Imported/Kick.wav"/>
+						<Path Value="C:/Users/Someone/Music/Set Project/Samples/Imported/Kick.wav"/>
+					</FileRef>
+				</SampleRef>
+				<SampleRef>
+					<FileRef>
+						<RelativePath Value="Samples/Imported/Kick.wav"/>
+						<Path Value="C:/Users/Someone/Music/Set Project/Samples/Imported/Kick.wav"/>
+					</FileRef>
+				</SampleRef>
+				<SampleRef>
+					<FileRef>
+						<RelativePath Value=""/>
+						<Path Value="/Volumes/Gone/Vocal.aif"/>
+					</FileRef>
+				</SampleRef>
+				<SampleRef>
+					<FileRef>
+						<RelativePath>
+							<RelativePathElement Dir=""/>
+							<RelativePathElement Dir="Shared"/>
+						</RelativePath>
+						<Name Value="Snare.wav"/>
+					</FileRef>
+				</SampleRef>
+			</AudioTrack>
+		</Tracks>
+	</LiveSet>
+</Ableton>`
+
+// Return whether each media file that a project refers to is missing, keyed by path. The paths of files that were found are cleaned so that they can be compared with the paths of test files.
+func mediaFilesMissing(pi *projectInformation) map[string]bool {
+	files := map[string]bool{}
+	for _, file := range pi.mediaFiles {
+		if file.missing {
+			files[file.path] = true
+		} else {
+			files[filepath.Clean(file.path)] = false
+		}
+	}
+	return files
+}
+
+func TestALSMediaFiles(t *testing.T) {
+	folder := t.TempDir()
+	kick := writeTestFile(t, filepath.Join(folder, "Set Project", "Samples", "Imported", "Kick.wav"), []byte("RIFF"))
+	snare := writeTestFile(t, filepath.Join(folder, "Shared", "Snare.wav"), []byte("RIFF"))
+	path := writeTestFile(t, filepath.Join(folder, "Set Project", "Set.als"), gzippedXML(t, testALSSamples))
+
+	pi := examineProject(path)
+	expected := map[string]bool{kick: false, snare: false, "/Volumes/Gone/Vocal.aif": true}
+	got := mediaFilesMissing(pi)
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+	if pi.missingMediaCount() != 1 {
+		t.Errorf("Expected 1 missing file, got %d", pi.missingMediaCount())
+	}
+
+	missingOnly := pi.mediaColouredString("", "", "", "", true)
+	if !strings.Contains(missingOnly, "missing /Volumes/Gone/Vocal.aif") || strings.Contains(missingOnly, "Kick.wav") {
+		t.Errorf("Expected only the missing file to be listed, got:\n%s", missingOnly)
+	}
+	if all := pi.mediaColouredString("", "", "", "", false); !strings.Contains(all, "found   "+kick) {
+		t.Errorf("Expected the found file to be listed, got:\n%s", all)
+	}
+	if !strings.Contains(pi.ColouredString("", "", "", "", ""), "Missing media files:\n /Volumes/Gone/Vocal.aif\n") {
+		t.Errorf("Expected the scan output to list the missing file")
+	}
+}
+
+func TestCPRMediaFiles(t *testing.T) {
+	folder := t.TempDir()
+	var versionArch, arrangementArch cprBuilder
+	versionArch.version("Cubase", "Version 13.0.21")
+	arrangementArch.track("VST Multitrack", "Vox").plugin("DSEQ3")
+	arrangementArch.mediaFile(`D:\Old Drive\Song\Audio\`, "Vox_01.wav")
+	arrangementArch.mediaFile("/Volumes/Gone/Audio", "Gtr_01.wav")
+	content := cprFile(nundFourcc,
+		cprRoot("Version"), cprChunk(archFourcc, versionArch.Bytes()),
+		cprRoot("Arrangement1"), cprChunk(archFourcc, arrangementArch.Bytes()))
+	vox := writeTestFile(t, filepath.Join(folder, "Song", "Audio", "Vox_01.wav"), []byte("RIFF"))
+	path := writeTestFile(t, filepath.Join(folder, "Song", "Song.cpr"), content)
+
+	pi := examineProject(path)
+	if pi.failed() {
+		t.Fatalf("Unexpected errors: %v", pi.errors)
+	}
+	expected := map[string]bool{vox: false, "/Volumes/Gone/Audio/Gtr_01.wav": true}
+	got := mediaFilesMissing(pi)
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+	if _, exists := pi.trackToPluginMap["Vox"]; !exists {
+		t.Errorf("Expected tracks to be found alongside media files, got %v", pi.trackToPluginMap)
+	}
+}
+
+func TestWindowsRelativeMediaPath(t *testing.T) {
+	folder := t.TempDir()
+	kick := writeTestFile(t, filepath.Join(folder, "Song", "Audio", "Kick.wav"), []byte("RIFF"))
+	pi := newProjectInformation(filepath.Join(folder, "Song", "Song.cpr"))
+
+	// Relative paths written on Windows are resolved on any operating system.
+	pi.addMediaReference(`D:\Old Drive\Song\Audio\Kick.wav`, `Audio\Kick.wav`)
+	if expected, got := map[string]bool{kick: false}, mediaFilesMissing(&pi); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestFindCPRMediaFiles(t *testing.T) {
+	var b cprBuilder
+	// A record with its folder before its file name.
+	b.str("FNPath").word(0).dword(0)
+	b.str("Path").word(0).str("/Audio")
+	b.str("FileName").word(0).str("Pad.wav")
+	// A record without a folder, which mustn't take the folder of the record that follows it.
+	b.str("FNPath").word(0).dword(0)
+	b.str("FileName").word(0).str("Loose.wav")
+	b.mediaFile("/Other", "Gtr.wav")
+
+	expected := []cubaseMediaFile{{path: "/Audio/Pad.wav", fileName: "Pad.wav"}, {path: "Loose.wav", fileName: "Loose.wav"}, {path: "/Other/Gtr.wav", fileName: "Gtr.wav"}}
+	if got := findMediaFiles(span{bytes: b.Bytes()}); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+}
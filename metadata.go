@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Musical and technical information about a project as a whole. Zero values mean that the project doesn't record the information, or that it couldn't be read.
+type projectMetadata struct {
+	creator     string        // The description of the application that created the project, as recorded by Live, such as "Ableton Live 11.3.13".
+	tempo       float64       // The tempo at the start of the project, in beats per minute.
+	numerator   int           // The number of beats in a bar of the time signature at the start of the project.
+	denominator int           // The note value of a beat of the time signature at the start of the project.
+	length      time.Duration // The length of the arrangement, from its start to the end of its last clip, item or region.
+	sampleRate  int           // The sample rate of the project, in Hz.
+	bitDepth    int           // The bit depth at which audio is recorded.
+}
+
+// A named item of metadata, formatted for display.
+type metadataField struct {
+	name  string
+	value string
+}
+
+// Return the time signature as text, such as "4/4", or an empty string if it isn't known.
+func (pm *projectMetadata) timeSignature() string {
+	if pm.numerator <= 0 || pm.denominator <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d/%d", pm.numerator, pm.denominator)
+}
+
+// Return the items of metadata that are known, formatted for display, in a consistent order.
+func (pm *projectMetadata) fields() []metadataField {
+	fields := []metadataField{}
+	if len(pm.creator) != 0 {
+		fields = append(fields, metadataField{"Creator", pm.creator})
+	}
+	if pm.tempo > 0 {
+		fields = append(fields, metadataField{"Tempo", formatTempo(pm.tempo) + " BPM"})
+	}
+	if signature := pm.timeSignature(); len(signature) != 0 {
+		fields = append(fields, metadataField{"Time signature", signature})
+	}
+	if pm.length > 0 {
+		fields = append(fields, metadataField{"Length", formatLength(pm.length)})
+	}
+	if pm.sampleRate > 0 {
+		fields = append(fields, metadataField{"Sample rate", fmt.Sprintf("%d Hz", pm.sampleRate)})
+	}
+	if pm.bitDepth > 0 {
+		fields = append(fields, metadataField{"Bit depth", fmt.Sprintf("%d bit", pm.bitDepth)})
+	}
+	return fields
+}
+
+// Format a tempo with up to three decimal places and no trailing zeros, so that 120 becomes "120" and 92.5 becomes "92.5".
+// Tempos stored as single precision floating point numbers are rounded to hide their representation error.
+func formatTempo(tempo float64) string {
+	return strconv.FormatFloat(math.Round(tempo*1000)/1000, 'f', -1, 64)
+}
+
+// Format a length to the nearest second, as minutes and seconds, or as hours, minutes and seconds for lengths of an hour or more.
+func formatLength(length time.Duration) string {
+	seconds := int(length.Round(time.Second) / time.Second)
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+	}
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+// Convert a length in beats to a duration at a constant [tempo]. Returns zero if the tempo isn't known.
+func beatsToDuration(beats, tempo float64) time.Duration {
+	if tempo <= 0 {
+		return 0
+	}
+	return secondsToDuration(beats * 60 / tempo)
+}
+
+// Convert a length in seconds to a duration.
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
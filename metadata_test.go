@@ -0,0 +1,155 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Check the metadata of a project against the expected values.
+func checkMetadata(t *testing.T, pi *projectInformation, expected projectMetadata) {
+	t.Helper()
+	if pi.failed() {
+		t.Fatalf("Unexpected errors: %v", pi.errors)
+	}
+	if pi.metadata != expected {
+		t.Errorf("Expected %+v, got %+v", expected, pi.metadata)
+	}
+}
+
+const testALSMetadata = `<?xml version="1.0" encoding="UTF-8"?>
+<Ableton MajorVersion="5" MinorVersion="11.0_11300" Creator="Ableton Live 11.3.13">
+	<LiveSet>
+		<Tracks>
+			<AudioTrack>
+				<DeviceChain>
+					<Mixer><Tempo><Manual Value="120"/></Tempo><TimeSignature><Manual Value="201"/></TimeSignature></Mixer>
+					<MainSequencer><Sample><ArrangerAutomation><Events>
+						<AudioClip Time="0"><CurrentEnd Value="64"/></AudioClip>
+						<AudioClip Time="64"><CurrentEnd Value="190"/></AudioClip>
+					</Events></ArrangerAutomation></Sample></MainSequencer>
+				</DeviceChain>
+			</AudioTrack>
+		</Tracks>
+		<MasterTrack>
+			<DeviceChain>
+				<Mixer><Tempo><Manual Value="95"/></Tempo><TimeSignature><Manual Value="203"/></TimeSignature></Mixer>
+			</DeviceChain>
+		</MasterTrack>
+	</LiveSet>
+</Ableton>`
+
+func TestALSMetadata(t *testing.T) {
+	path := writeTestFile(t, filepath.Join(t.TempDir(), "Set.als"), gzippedXML(t, testALSMetadata))
+	pi := examineProject(path)
+	// 190 beats at 95 BPM is two minutes. Only the master track's tempo and time signature of 6/4 count.
+	checkMetadata(t, pi, projectMetadata{creator: "Ableton Live 11.3.13", tempo: 95, numerator: 6, denominator: 4, length: 2 * time.Minute})
+	if pi.application != "Ableton Live" {
+		t.Errorf("Expected the application to be Ableton Live, got %q", pi.application)
+	}
+}
+
+func TestCPRMetadata(t *testing.T) {
+	var versionArch, arrangementArch cprBuilder
+	versionArch.version("Cubase", "Version 13.0.21")
+	arrangementArch.projectSetup(48000, 24, 128.5).track("VST Multitrack", "Bass").plugin("DUNE 3")
+	pi := examineCPRContent("Setup.cpr", cprFile(nundFourcc,
+		cprRoot("Version"), cprChunk(archFourcc, versionArch.Bytes()),
+		cprRoot("Arrangement1"), cprChunk(archFourcc, arrangementArch.Bytes())))
+	checkMetadata(t, pi, projectMetadata{tempo: 128.5, sampleRate: 48000, bitDepth: 24})
+}
+
+func TestFLPMetadata(t *testing.T) {
+	b := flpBuilder{unicode: true}
+	b.data(FE_Version, append([]byte("20.8.4.2576"), 0))
+	b.dword(FE_Tempo, 140500).byteValue(FE_TimeSignatureNumerator, 7).byteValue(FE_TimeSignatureBeat, 8)
+	// Channels have events with the same IDs, which don't affect the project's time signature.
+	b.word(FE_ChannelNew, 0).byteValue(FE_TimeSignatureNumerator, 3).text(FE_PluginInternalName, "Sytrus")
+	path := writeTestFile(t, filepath.Join(t.TempDir(), "Beat.flp"), flpFile(b.Bytes()))
+	checkMetadata(t, examineProject(path), projectMetadata{tempo: 140.5, numerator: 7, denominator: 8})
+}
+
+func TestRPPMetadata(t *testing.T) {
+	const content = `<REAPER_PROJECT 0.1 "7.11/win64" 1712345678
+  TEMPO 92 3 4
+  SAMPLERATE 44100 0 0
+  <TRACK
+    NAME Drums
+    <ITEM
+      POSITION 10
+      LENGTH 50.5
+    >
+    <ITEM
+      POSITION 2
+      LENGTH 4
+    >
+  >
+>
+`
+	path := writeTestFile(t, filepath.Join(t.TempDir(), "Mix.rpp"), []byte(content))
+	checkMetadata(t, examineProject(path), projectMetadata{tempo: 92, numerator: 3, denominator: 4, length: 60500 * time.Millisecond, sampleRate: 44100})
+}
+
+func TestArdourMetadata(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		tempoMap string
+		location string
+	}{
+		{
+			"Ardour 8",
+			`<TempoMap superclocks-per-second="282240000"><Tempos><Tempo npm="110" note-type="4"/></Tempos><Meters><Meter note-value="8" divisions-per-bar="6"/></Meters></TempoMap>`,
+			`<Location name="session" start="a0" end="a25401600000" flags="IsSessionRange"/>`,
+		},
+		{
+			"Ardour 6",
+			`<TempoMap><Tempo beats-per-minute="110" note-type="4"/><Meter note-type="8" divisions-per-bar="6"/></TempoMap>`,
+			`<Location name="session" start="48000" end="4368000" flags="IsSessionRange"/>`,
+		},
+	} {
+		content := `<?xml version="1.0" encoding="UTF-8"?>
+<Session version="7003" name="Film" sample-rate="48000">
+  <Locations>
+    <Location name="Loop" start="0" end="96000000" flags="IsAutoLoop"/>
+    ` + test.location + `
+  </Locations>
+  ` + test.tempoMap + `
+</Session>`
+		path := writeTestFile(t, filepath.Join(t.TempDir(), "Film.ardour"), []byte(content))
+		t.Run(test.name, func(t *testing.T) {
+			checkMetadata(t, examineProject(path), projectMetadata{tempo: 110, numerator: 6, denominator: 8, length: 90 * time.Second, sampleRate: 48000})
+		})
+	}
+}
+
+func TestSongMetadata(t *testing.T) {
+	metaInfo := strings.Replace(testSongMetaInfo, "</MetaInformation>", `<Attribute id="Media:SampleRate" value="96000"/></MetaInformation>`, 1)
+	song := `<?xml version="1.0" encoding="UTF-8"?>
+<Song>
+	<Attributes x:id="Root">
+		<TempoMap x:id="tempoMap"><TempoMapSegment start="0" tempo="100"/><TempoMapSegment start="8" tempo="104"/></TempoMap>
+		<TimeSignatureMap x:id="timeSignatureMap"><TimeSignatureMapSegment numerator="5" denominator="4"/></TimeSignatureMap>
+	</Attributes>
+</Song>`
+	path := writeTestFile(t, filepath.Join(t.TempDir(), "Single.song"), zipArchive(t, songMetaInfoEntry, metaInfo, songSongEntry, song))
+	checkMetadata(t, examineProject(path), projectMetadata{tempo: 100, numerator: 5, denominator: 4, sampleRate: 96000})
+}
+
+func TestMetadataFields(t *testing.T) {
+	pm := projectMetadata{tempo: 119.99999237060547, numerator: 4, denominator: 4, length: 3*time.Hour + 25*time.Second, bitDepth: 24}
+	var lines []string
+	for _, field := range pm.fields() {
+		lines = append(lines, field.name+": "+field.value)
+	}
+	expected := "Tempo: 120 BPM|Time signature: 4/4|Length: 3:00:25|Bit depth: 24 bit"
+	if got := strings.Join(lines, "|"); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+	if got := formatLength(205 * time.Second); got != "3:25" {
+		t.Errorf("Expected 3:25, got %q", got)
+	}
+	if fields := (&projectMetadata{}).fields(); len(fields) != 0 {
+		t.Errorf("Expected no fields for unknown metadata, got %v", fields)
+	}
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattn/go-isatty"
+)
+
+// The number of slowest files listed in the final statistics.
+const slowestFileCount = 5
+
+// The interval at which the live progress line is redrawn, whether or not any files have finished, so that a file that is taking a long time to examine can be seen.
+const progressInterval = 250 * time.Millisecond
+
+// The time taken to examine a single project file.
+type fileTiming struct {
+	path    string
+	elapsed time.Duration
+}
+
+// Statistics gathered during a run, optionally shown as a live progress line and as a final summary. The methods may be called from any goroutine.
+type runStatistics struct {
+	mutex          sync.Mutex
+	output         io.Writer
+	live           bool
+	started        time.Time
+	lastDrawn      time.Time
+	discovered     int
+	parsed         int
+	failed         int
+	skipped        int
+	bytesTotal     int64
+	bytesProcessed int64
+	sizes          map[string]int64
+	inProgress     map[string]time.Time // The files being examined, and when each was started.
+	formatCounts   map[string]int
+	timings        []fileTiming
+	stopTicker     chan struct{}
+	tickerStopped  chan struct{}
+}
+
+// Create and initialize a new runStatistics instance for the project files in [paths].
+// The live progress line is only drawn when stderr is a terminal.
+func newRunStatistics(paths []string) *runStatistics {
+	rs := &runStatistics{
+		output:       os.Stderr,
+		live:         isatty.IsTerminal(os.Stderr.Fd()),
+		started:      time.Now(),
+		discovered:   len(paths),
+		inProgress:   map[string]time.Time{},
+		formatCounts: map[string]int{},
+	}
+	rs.sizes, rs.bytesTotal = estimateFileSizes(paths)
+	return rs
+}
+
+// Estimate the number of bytes that examining each project file involves, and the total. Only files on disk are measured, so that archives aren't opened before the run starts:
+// the size of an archive is shared between the project files within it.
+func estimateFileSizes(paths []string) (map[string]int64, int64) {
+	entries := map[string][]string{}
+	for _, path := range paths {
+		file := outermostFile(path)
+		entries[file] = append(entries[file], path)
+	}
+
+	sizes := map[string]int64{}
+	total := int64(0)
+	for file, paths := range entries {
+		fileInfo, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		size := fileInfo.Size()
+		share := size / int64(len(paths))
+		for _, path := range paths {
+			sizes[path] = share
+		}
+		// The first file takes any remainder, so that the shares add up to the size of the archive.
+		sizes[paths[0]] += size - share*int64(len(paths))
+		total += size
+	}
+	return sizes, total
+}
+
+// Start redrawing the live progress line at regular intervals, until [stop] is called. Does nothing if the progress line isn't shown.
+func (rs *runStatistics) start() {
+	if !rs.live {
+		return
+	}
+	rs.stopTicker, rs.tickerStopped = make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(rs.tickerStopped)
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				rs.mutex.Lock()
+				rs.draw()
+				rs.mutex.Unlock()
+			case <-rs.stopTicker:
+				return
+			}
+		}
+	}()
+}
+
+// Stop redrawing the live progress line, waiting for any redraw in progress to finish.
+func (rs *runStatistics) stop() {
+	if rs.stopTicker != nil {
+		close(rs.stopTicker)
+		<-rs.tickerStopped
+		rs.stopTicker = nil
+	}
+}
+
+// Record that a project file is about to be examined.
+func (rs *runStatistics) begin(path string) {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+	rs.inProgress[path] = time.Now()
+}
+
+// Record the result of examining a project.
+func (rs *runStatistics) record(pi *projectInformation) {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+	delete(rs.inProgress, pi.path)
+	rs.parsed++
+	if pi.failed() {
+		rs.failed++
+	}
+	rs.bytesProcessed += rs.sizes[pi.path]
+	if pi.format != PF_Unknown {
+		rs.formatCounts[pi.format.String()]++
+	} else {
+		rs.formatCounts[strings.ToLower(filepath.Ext(pi.path))]++
+	}
+	rs.timings = append(rs.timings, fileTiming{path: pi.path, elapsed: pi.elapsed})
+}
+
+// Record that a file was skipped because its content wasn't recognised as a project.
+func (rs *runStatistics) skip(path string) {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+	delete(rs.inProgress, path)
+	rs.skipped++
+	rs.bytesProcessed += rs.sizes[path]
+}
+
+// Run [print] with the live progress line erased, so that its output isn't mixed up with the progress line. The line is redrawn at the next interval.
+func (rs *runStatistics) withoutProgress(print func()) {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+	rs.clear()
+	print()
+}
+
+// Estimate the time remaining based on the rate at which bytes have been processed so far.
+func (rs *runStatistics) eta() string {
+	if rs.bytesProcessed == 0 {
+		return "?"
+	}
+	elapsed := time.Since(rs.started)
+	remaining := time.Duration(float64(elapsed) * float64(rs.bytesTotal-rs.bytesProcessed) / float64(rs.bytesProcessed))
+	return remaining.Round(time.Second).String()
+}
+
+// Describe the files being examined, naming the one that has been running the longest, so that a file that is hanging a parser stands out from a slow disk or network share.
+func (rs *runStatistics) describeInProgress() string {
+	if len(rs.inProgress) == 0 {
+		return ""
+	}
+	oldestPath, oldestStart := "", time.Time{}
+	for path, started := range rs.inProgress {
+		if len(oldestPath) == 0 || started.Before(oldestStart) || (started.Equal(oldestStart) && path < oldestPath) {
+			oldestPath, oldestStart = path, started
+		}
+	}
+	return fmt.Sprintf(", %d in progress, longest %s (%s)", len(rs.inProgress), filepath.Base(oldestPath), time.Since(oldestStart).Round(time.Second))
+}
+
+// Draw the live progress line, overwriting any previous one. The caller must hold the lock.
+func (rs *runStatistics) draw() {
+	fmt.Fprintf(rs.output, "\r\033[K%d discovered, %d parsed, %d failed, %d skipped, %s of %s, %s elapsed, ETA %s%s",
+		rs.discovered, rs.parsed, rs.failed, rs.skipped, formatBytes(rs.bytesProcessed), formatBytes(rs.bytesTotal),
+		time.Since(rs.started).Round(time.Second), rs.eta(), rs.describeInProgress())
+	rs.lastDrawn = time.Now()
+}
+
+// Erase the live progress line so that other output can be written cleanly. The caller must hold the lock.
+func (rs *runStatistics) clear() {
+	if rs.live && !rs.lastDrawn.IsZero() {
+		fmt.Fprint(rs.output, "\r\033[K")
+		rs.lastDrawn = time.Time{}
+	}
+}
+
+// Write the final statistics block.
+func (rs *runStatistics) summarize() {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+	rs.clear()
+
+	total := time.Since(rs.started)
+	fmt.Fprintf(rs.output, "\nStatistics:\n")
+	fmt.Fprintf(rs.output, "  Total time: %s\n", total.Round(time.Millisecond))
+	fmt.Fprintf(rs.output, "  Files: %d discovered, %d parsed, %d failed, %d skipped\n", rs.discovered, rs.parsed, rs.failed, rs.skipped)
+	fmt.Fprintf(rs.output, "  Bytes processed: %s\n", formatBytes(rs.bytesProcessed))
+
+	if len(rs.formatCounts) != 0 {
+		fmt.Fprintf(rs.output, "  Files per format:\n")
+		iterateOverCISortedMap(rs.formatCounts, func(format string, count int) {
+			fmt.Fprintf(rs.output, "    %s%d\n", padStringToWidth(format, 12, ' '), count)
+		})
+	}
+
+	if len(rs.timings) != 0 {
+		sort.Slice(rs.timings, func(i, j int) bool {
+			return rs.timings[i].elapsed > rs.timings[j].elapsed
+		})
+		fmt.Fprintf(rs.output, "  Slowest files:\n")
+		for _, timing := range rs.timings[:min(slowestFileCount, len(rs.timings))] {
+			fmt.Fprintf(rs.output, "    %s  %s\n", padStringToWidth(timing.elapsed.Round(time.Millisecond).String(), 10, ' '), timing.path)
+		}
+	}
+}
+
+// Format a byte count using binary units.
+func formatBytes(count int64) string {
+	const unit = 1024
+	if count < unit {
+		return fmt.Sprintf("%d B", count)
+	}
+	value, exponent := float64(count)/unit, 0
+	for value >= unit && exponent < 4 {
+		value /= unit
+		exponent++
+	}
+	return fmt.Sprintf("%.1f %ciB", value, "KMGTP"[exponent])
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEstimateFileSizes(t *testing.T) {
+	folder := t.TempDir()
+	plain := writeTestFile(t, filepath.Join(folder, "Mix.rpp"), []byte("12345"))
+	content := zipArchive(t, "A.als", "a", "B.als", "b", "C.als", "c")
+	archive := writeTestFile(t, filepath.Join(folder, "Sets.zip"), content)
+	entries := []string{archive + "!/A.als", archive + "!/B.als", archive + "!/C.als"}
+	missing := filepath.Join(folder, "Missing.als")
+
+	sizes, total := estimateFileSizes(append([]string{plain, missing}, entries...))
+	archiveSize := int64(len(content))
+	if total != 5+archiveSize {
+		t.Errorf("Expected a total of %d bytes, got %d", 5+archiveSize, total)
+	}
+	if sizes[plain] != 5 {
+		t.Errorf("Expected the size of the file to be 5, got %d", sizes[plain])
+	}
+	if _, found := sizes[missing]; found {
+		t.Errorf("Expected no size for a missing file")
+	}
+	shared := int64(0)
+	for _, entry := range entries {
+		shared += sizes[entry]
+	}
+	if shared != archiveSize {
+		t.Errorf("Expected the entries to share the %d bytes of the archive, got %d", archiveSize, shared)
+	}
+}
+
+func TestRunStatistics(t *testing.T) {
+	var output bytes.Buffer
+	rs := &runStatistics{
+		output:       &output,
+		started:      time.Now(),
+		discovered:   3,
+		sizes:        map[string]int64{"Good.als": 100, "Bad.als": 50, "Notes.xml": 50},
+		bytesTotal:   200,
+		inProgress:   map[string]time.Time{},
+		formatCounts: map[string]int{},
+	}
+
+	good := newProjectInformation("Good.als")
+	good.format = PF_ALS
+	bad := newProjectInformation("Bad.als")
+	bad.logError(newProjectError(EK_NotGzip, errors.New("bad magic")))
+	for _, pi := range []*projectInformation{&good, &bad} {
+		rs.begin(pi.path)
+		rs.record(pi)
+	}
+	rs.begin("Notes.xml")
+	rs.skip("Notes.xml")
+
+	if rs.parsed != 2 || rs.failed != 1 || rs.skipped != 1 || rs.bytesProcessed != rs.bytesTotal || len(rs.inProgress) != 0 {
+		t.Errorf("Expected every file to be accounted for, got %d parsed, %d failed, %d skipped, %d of %d bytes, %d in progress",
+			rs.parsed, rs.failed, rs.skipped, rs.bytesProcessed, rs.bytesTotal, len(rs.inProgress))
+	}
+
+	rs.summarize()
+	if summary := output.String(); !strings.Contains(summary, "Files: 3 discovered, 2 parsed, 1 failed, 1 skipped") {
+		t.Errorf("Unexpected summary %q", summary)
+	}
+}
+
+func TestProgressShowsFilesInProgress(t *testing.T) {
+	var output bytes.Buffer
+	rs := newRunStatistics(nil)
+	rs.output = &output
+	rs.live = true
+
+	// The progress line is redrawn while a file is being examined, even though no file has finished.
+	rs.begin(filepath.Join("Sets", "Hung.als"))
+	rs.start()
+	time.Sleep(3 * progressInterval)
+	rs.stop()
+
+	if line := output.String(); !strings.Contains(line, "1 in progress, longest Hung.als") || !strings.Contains(line, "elapsed") {
+		t.Errorf("Expected the progress line to name the file in progress, got %q", line)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[int64]string{0: "0 B", 1023: "1023 B", 1024: "1.0 KiB", 1536: "1.5 KiB", 5 << 20: "5.0 MiB"}
+	for count, expected := range tests {
+		if got := formatBytes(count); got != expected {
+			t.Errorf("formatBytes(%d): expected %q, got %q", count, expected, got)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kinds of error that can be encountered while examining a project.
+type errorKind int
+
+const (
+	EK_IO errorKind = iota
+	EK_NotGzip
+	EK_NotZip
+	EK_MalformedXML
+	EK_BadRIFFMagic
+	EK_TruncatedChunk
+	EK_BadHeader
+	EK_MalformedText
+	EK_Panic
+	EK_Timeout
+)
+
+// Returns a short description of an error kind.
+func (ek errorKind) String() string {
+	switch ek {
+	case EK_IO:
+		return "I/O error"
+	case EK_NotGzip:
+		return "not gzip"
+	case EK_NotZip:
+		return "not zip"
+	case EK_MalformedXML:
+		return "malformed XML"
+	case EK_BadRIFFMagic:
+		return "bad RIFF magic"
+	case EK_TruncatedChunk:
+		return "truncated chunk"
+	case EK_BadHeader:
+		return "bad header"
+	case EK_MalformedText:
+		return "malformed text"
+	case EK_Panic:
+		return "panic"
+	case EK_Timeout:
+		return "timeout"
+	default:
+		return fmt.Sprintf("error kind %d", int(ek))
+	}
+}
+
+// An error encountered while examining a project, along with where in the file it was found, if known.
+type projectError struct {
+	kind   errorKind
+	offset int    // The byte offset within the file, or -1 if not known.
+	chunk  string // The name of the chunk being read, if any.
+	err    error
+}
+
+// Create a projectError that isn't associated with a location in the file.
+func newProjectError(kind errorKind, err error) projectError {
+	return projectError{kind: kind, offset: -1, err: err}
+}
+
+// Create a projectError that is associated with a chunk at a byte offset within the file.
+func newProjectErrorAt(kind errorKind, chunk string, offset int, err error) projectError {
+	return projectError{kind: kind, offset: offset, chunk: chunk, err: err}
+}
+
+// Describe the error, including its kind and location.
+func (pe projectError) Error() string {
+	var sb strings.Builder
+	sb.WriteString(pe.kind.String())
+	if len(pe.chunk) != 0 {
+		sb.WriteString(" in " + pe.chunk + " chunk")
+	}
+	if pe.offset >= 0 {
+		sb.WriteString(fmt.Sprintf(" at offset 0x%08x", pe.offset))
+	}
+	if pe.err != nil {
+		sb.WriteString(": " + pe.err.Error())
+	}
+	return sb.String()
+}
+
+// Return the underlying error.
+func (pe projectError) Unwrap() error {
+	return pe.err
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+)
+
+func TestProjectErrorString(t *testing.T) {
+	tests := []struct {
+		err      projectError
+		expected string
+	}{
+		{newProjectError(EK_NotGzip, errors.New("bad magic")), "not gzip: bad magic"},
+		{newProjectErrorAt(EK_TruncatedChunk, "ARCH", 0x1234, io.ErrUnexpectedEOF), "truncated chunk in ARCH chunk at offset 0x00001234: unexpected EOF"},
+		{newProjectErrorAt(EK_BadHeader, "", 8, nil), "bad header at offset 0x00000008"},
+		{newProjectError(EK_Timeout, nil), "timeout"},
+		{newProjectError(errorKind(99), nil), "error kind 99"},
+	}
+	for _, test := range tests {
+		if got := test.err.Error(); got != test.expected {
+			t.Errorf("Expected %q, got %q", test.expected, got)
+		}
+	}
+
+	if err := newProjectError(EK_IO, io.ErrUnexpectedEOF); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Expected the underlying error to be unwrapped")
+	}
+}
+
+func TestExamineExitCode(t *testing.T) {
+	folder := t.TempDir()
+	good := writeTestFile(t, filepath.Join(folder, "Good.rpp"), []byte(testRPP))
+	bad := writeTestFile(t, filepath.Join(folder, "Bad.als"), []byte("plain text"))
+	unfamiliar := writeTestFile(t, filepath.Join(folder, "Unfamiliar.als"), gzippedXML(t, `<Ableton MajorVersion="6"><LiveSet/></Ableton>`))
+
+	so := scanOptions{numThreads: 1}
+	if exitCode := so.examine([]string{good, unfamiliar}, func(*projectInformation) {}); exitCode != 0 {
+		t.Errorf("Expected exit code 0 when every project is examined, even with warnings, got %d", exitCode)
+	}
+	if exitCode := so.examine([]string{good, bad}, func(*projectInformation) {}); exitCode != 1 {
+		t.Errorf("Expected exit code 1 when a project fails, got %d", exitCode)
+	}
+}
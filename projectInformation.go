@@ -4,6 +4,7 @@ import (
 	"os"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/mattn/go-isatty"
 )
@@ -11,10 +12,18 @@ import (
 // Information about a project.
 type projectInformation struct {
 	path             string
+	format           projectFormat
+	backupOf         string // The path of the project that this file is a backup of, if any.
+	application      string // The name of the application that wrote the project, if known.
 	version          string
+	metadata         projectMetadata // The tempo, time signature and other information about the project as a whole, where known.
 	pluginToTrackMap map[string][]string
 	trackToPluginMap map[string][]string
-	errors           []string
+	pluginVendors    map[string]string
+	mediaFiles       []mediaReference // The external media files, such as samples, that the project refers to.
+	errors           []projectError
+	warnings         []string      // Problems that didn't stop the project from being examined, such as a version that the parser doesn't recognise.
+	elapsed          time.Duration // The time taken to examine the project.
 }
 
 // Create and initialize a new projectInformation instance.
@@ -24,13 +33,24 @@ func newProjectInformation(projectPath string) projectInformation {
 		version:          "<unknown version>",
 		pluginToTrackMap: map[string][]string{},
 		trackToPluginMap: map[string][]string{},
-		errors:           []string{},
+		pluginVendors:    map[string]string{},
+		errors:           []projectError{},
 	}
 }
 
-// Log an error message against a project.
-func (pi *projectInformation) logError(text string) {
-	pi.errors = append(pi.errors, text)
+// Log an error against a project.
+func (pi *projectInformation) logError(err projectError) {
+	pi.errors = append(pi.errors, err)
+}
+
+// Log a warning against a project. Unlike errors, warnings don't mark the project as failed.
+func (pi *projectInformation) logWarning(warning string) {
+	pi.warnings = append(pi.warnings, warning)
+}
+
+// Check whether any errors were encountered while examining a project.
+func (pi *projectInformation) failed() bool {
+	return len(pi.errors) != 0
 }
 
 // Map a plugin to a track, and the reverse (track to plugin).
@@ -39,6 +59,36 @@ func (pi *projectInformation) mapTrackToPlugin(plugin, track string) {
 	pi.trackToPluginMap[track] = append(pi.trackToPluginMap[track], plugin)
 }
 
+// Record the vendor of a plugin, if it is known.
+func (pi *projectInformation) setPluginVendor(plugin, vendor string) {
+	if len(vendor) != 0 {
+		pi.pluginVendors[plugin] = vendor
+	}
+}
+
+// Return a copy of a project that includes only the plugin and track pairs for which [keep] returns true.
+func (pi *projectInformation) filtered(keep func(plugin, track string) bool) *projectInformation {
+	info := newProjectInformation(pi.path)
+	info.format = pi.format
+	info.backupOf = pi.backupOf
+	info.application = pi.application
+	info.version = pi.version
+	info.metadata = pi.metadata
+	info.mediaFiles = pi.mediaFiles
+	info.errors = pi.errors
+	info.warnings = pi.warnings
+	info.elapsed = pi.elapsed
+	for plugin, tracks := range pi.pluginToTrackMap {
+		for _, track := range tracks {
+			if keep(plugin, track) {
+				info.mapTrackToPlugin(plugin, track)
+				info.setPluginVendor(plugin, pi.pluginVendors[plugin])
+			}
+		}
+	}
+	return &info
+}
+
 // Generate a coloured description of a project.
 func (pi *projectInformation) ColouredString(projectColour, keyColour, valueColour, errorColour, resetColour string) string {
 	type mapType int
@@ -50,7 +100,18 @@ func (pi *projectInformation) ColouredString(projectColour, keyColour, valueColo
 
 	var sb strings.Builder
 
-	sb.WriteString(projectColour + "Project: " + pi.path + "\nVersion: " + pi.version + resetColour + "\n\n")
+	sb.WriteString(projectColour + "Project: " + pi.path + "\n")
+	if len(pi.backupOf) != 0 {
+		sb.WriteString("Backup of: " + pi.backupOf + "\n")
+	}
+	if len(pi.application) != 0 {
+		sb.WriteString("Application: " + pi.application + "\n")
+	}
+	sb.WriteString("Version: " + pi.version + "\n")
+	for _, field := range pi.metadata.fields() {
+		sb.WriteString(field.name + ": " + field.value + "\n")
+	}
+	sb.WriteString(resetColour + "\n")
 
 	for _, mt := range []mapType{mapPluginsToTracks, mapTracksToPlugins} {
 		displayMap := pi.pluginToTrackMap
@@ -81,10 +142,26 @@ func (pi *projectInformation) ColouredString(projectColour, keyColour, valueColo
 		sb.WriteString("\n")
 	}
 
+	if pi.missingMediaCount() != 0 {
+		sb.WriteString(errorColour + "Missing media files:" + resetColour + "\n")
+		for _, file := range pi.sortedMediaFiles() {
+			if file.missing {
+				sb.WriteString(" " + errorColour + file.path + resetColour + "\n")
+			}
+		}
+	}
+
+	if len(pi.warnings) != 0 {
+		sb.WriteString(keyColour + "Warnings:" + resetColour + "\n")
+		for _, warning := range pi.warnings {
+			sb.WriteString(" " + valueColour + warning + resetColour + "\n")
+		}
+	}
+
 	if len(pi.errors) != 0 {
 		sb.WriteString(errorColour + "Errors:" + resetColour + "\n")
 		for _, err := range pi.errors {
-			sb.WriteString(" " + errorColour + err + resetColour + "\n")
+			sb.WriteString(" " + errorColour + err.Error() + resetColour + "\n")
 		}
 	}
 
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Filters that select the projects, and the plugin and track pairs within them, that a query reports.
+type projectFilter struct {
+	plugin     textMatcher
+	track      textMatcher
+	vendor     textMatcher
+	version    textMatcher
+	filterRows bool // Whether any of the plugin, track and vendor patterns were given, so that projects without a matching row are left out.
+}
+
+// Create a projectFilter from the patterns given on the command line. Empty patterns match anything.
+func newProjectFilter(plugin, track, vendor, version string) (projectFilter, error) {
+	pf := projectFilter{filterRows: len(plugin)+len(track)+len(vendor) != 0}
+	for _, field := range []struct {
+		matcher *textMatcher
+		pattern string
+	}{
+		{&pf.plugin, plugin},
+		{&pf.track, track},
+		{&pf.vendor, vendor},
+		{&pf.version, version},
+	} {
+		if len(field.pattern) == 0 {
+			*field.matcher = matchAnything
+			continue
+		}
+		matcher, err := newTextMatcher(field.pattern)
+		if err != nil {
+			return pf, err
+		}
+		*field.matcher = matcher
+	}
+	return pf, nil
+}
+
+// Apply the filter to a project. Returns a copy of the project containing only the matching plugin and track pairs, or nil if there are none.
+// If only the version pattern was given, the project is returned whole when its version matches, even if it has no plugins.
+func (pf *projectFilter) apply(pi *projectInformation) *projectInformation {
+	if !pf.version(pi.version) {
+		return nil
+	}
+	if !pf.filterRows {
+		return pi
+	}
+	matches := pi.filtered(func(plugin, track string) bool {
+		return pf.plugin(plugin) && pf.track(track) && pf.vendor(pi.pluginVendors[plugin])
+	})
+	if len(matches.pluginToTrackMap) == 0 {
+		return nil
+	}
+	return matches
+}
+
+// Run the query command, which prints only the projects, and the rows within them, that match the given filters and expression.
+func runQuery(args []string) int {
+	fs := newCommandFlagSet("query", "[-where <expression>] [-plugin <pattern>] [-track <pattern>] [-vendor <pattern>] [-version <pattern>] [flags] <file|folder> [<file|folder> ...]")
+	var options scanOptions
+	options.register(fs)
+	var whereFlag = fs.String("where", "", "Only show projects for which this expression is true, e.g. 'plugin:\"VPS Avenger\" and not plugin:\"Surge XT\" and version>=11.0'.")
+	var pluginFlag = fs.String("plugin", "", "Only show plugins whose name matches this pattern.")
+	var trackFlag = fs.String("track", "", "Only show tracks whose name matches this pattern.")
+	var vendorFlag = fs.String("vendor", "", "Only show plugins whose vendor matches this pattern.")
+	var versionFlag = fs.String("version", "", "Only show projects whose version matches this pattern.")
+	var formatOptions outputOptions
+	formatOptions.register(fs)
+	fs.Parse(args)
+
+	hasFilter := len(*pluginFlag)+len(*trackFlag)+len(*vendorFlag)+len(*versionFlag) != 0
+	if fs.NArg() < 1 || (!hasFilter && len(*whereFlag) == 0) {
+		fs.Usage()
+		fmt.Fprintf(os.Stderr, "\nPatterns are case-insensitive. Plain text matches names that contain it, globs (*, ? and [...]) must match the whole name, and /expression/ is a regular expression.\n")
+		return 2
+	}
+
+	filter, err := newProjectFilter(*pluginFlag, *trackFlag, *vendorFlag, *versionFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	var where queryExpr
+	if len(*whereFlag) != 0 {
+		if where, err = parseExpr(*whereFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n  %s\n", err, *whereFlag)
+			var syntaxError *exprSyntaxError
+			if errors.As(err, &syntaxError) {
+				fmt.Fprintf(os.Stderr, "  %s^\n", strings.Repeat(" ", syntaxError.column-1))
+			}
+			return 2
+		}
+	}
+
+	output, err := newReportOutput(formatOptions)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	return output.finishCommand(options.scan(fs.Args(), func(pi *projectInformation) {
+		if where != nil && !where.eval(pi) {
+			return
+		}
+		if hasFilter {
+			pi = filter.apply(pi)
+		}
+		if pi != nil {
+			output.add(pi)
+		}
+	}))
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProjectFilter(t *testing.T) {
+	pi := newProjectInformation("43.als")
+	pi.version = "11.0_11300"
+	pi.mapTrackToPlugin("DUNE 3", "5 D3.RBass1")
+	pi.mapTrackToPlugin("DUNE 3", "12 D3.Sqr")
+	pi.mapTrackToPlugin("StandardCLIP", "5 D3.RBass1")
+	pi.setPluginVendor("DUNE 3", "Synapse Audio")
+
+	filter, err := newProjectFilter("DUNE 3", "*Bass*", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	matches := filter.apply(&pi)
+	if matches == nil {
+		t.Fatal("Expected the project to match")
+	}
+	expected := map[string][]string{"5 D3.RBass1": {"DUNE 3"}}
+	if !reflect.DeepEqual(matches.trackToPluginMap, expected) {
+		t.Errorf("Expected %v, got %v", expected, matches.trackToPluginMap)
+	}
+
+	filter, _ = newProjectFilter("", "", "synapse", "")
+	if matches := filter.apply(&pi); matches == nil || len(matches.pluginToTrackMap["DUNE 3"]) != 2 || len(matches.pluginToTrackMap) != 1 {
+		t.Errorf("Expected only the DUNE 3 rows to match the vendor filter, got %v", matches)
+	}
+
+	filter, _ = newProjectFilter("DUNE 3", "", "", "12.*")
+	if matches := filter.apply(&pi); matches != nil {
+		t.Errorf("Expected the version filter to exclude the project, got %v", matches)
+	}
+
+	// The version filter on its own selects projects that have no plugins too.
+	empty := newProjectInformation("Empty.als")
+	empty.version = "11.0_11300"
+	filter, _ = newProjectFilter("", "", "", "11.*")
+	for _, project := range []*projectInformation{&pi, &empty} {
+		if matches := filter.apply(project); matches != project {
+			t.Errorf("Expected %s to match the version filter whole, got %v", project.path, matches)
+		}
+	}
+}
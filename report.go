@@ -0,0 +1,249 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// The formats in which the scan, query and report commands can write their results.
+type outputFormat int
+
+const (
+	OF_Text outputFormat = iota
+	OF_HTML
+	OF_Markdown
+)
+
+// The names by which output formats are chosen on the command line, indexed by format.
+var outputFormatNames = []string{"text", "html", "markdown"}
+
+// Returns the name of an output format.
+func (of *outputFormat) String() string {
+	if int(*of) < len(outputFormatNames) {
+		return outputFormatNames[*of]
+	}
+	return fmt.Sprintf("outputFormat(%d)", int(*of))
+}
+
+// Choose an output format by name.
+func (of *outputFormat) Set(value string) error {
+	for index, name := range outputFormatNames {
+		if strings.EqualFold(value, name) {
+			*of = outputFormat(index)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown output format %q (expected %s)", value, strings.Join(outputFormatNames, ", "))
+}
+
+// Options that control how the results of a command are written. They are shared by the commands that can write documents.
+type outputOptions struct {
+	format       outputFormat
+	summary      bool
+	templatePath string
+}
+
+// Register the output options with a command's flag set.
+func (oo *outputOptions) register(fs *flag.FlagSet) {
+	fs.Var(&oo.format, "format", "The output format, one of "+strings.Join(outputFormatNames, ", ")+" (default text). Formats other than text are documents that are written once all projects have been examined.")
+	fs.BoolVar(&oo.summary, "summary", true, "Include a summary of the library, listing the projects and tracks that use each plugin, in documents.")
+	fs.StringVar(&oo.templatePath, "template", "", "A Go text/template file to render each project with, instead of using -format. A template named \""+libraryTemplateName+"\" in the file, if there is one, is rendered with the summary of the library at the end.")
+}
+
+// A track or plugin, and the plugins or tracks associated with it, sorted and de-duplicated for display.
+type reportRow struct {
+	Key    string
+	Values []string
+}
+
+// A project, prepared for display in a document.
+type reportProject struct {
+	ID           int // Identifies the project within the document.
+	Path         string
+	Format       string
+	BackupOf     string
+	Application  string
+	Version      string
+	Metadata     []reportField
+	Plugins      []reportRow // Each plugin, followed by the tracks within which it appears.
+	Tracks       []reportRow // Each track, followed by the plugins that it uses.
+	MissingMedia []string
+	Warnings     []string
+	Errors       []string
+}
+
+// A named item of metadata, formatted for display.
+type reportField struct {
+	Name  string
+	Value string
+}
+
+// A plugin in the library summary, with the projects within which it appears and the number of tracks that use it.
+type reportPlugin struct {
+	Name       string
+	Projects   []string
+	TrackCount int
+}
+
+// The results of a scan, prepared for display in a document.
+type report struct {
+	Projects     []reportProject
+	ProjectCount int
+	Summary      bool // Whether the document includes the summary of the library.
+	Plugins      []reportPlugin
+}
+
+// Return the entries of a map of names to lists of names as rows, sorted case-insensitively, with each list sorted and de-duplicated.
+func reportRows(m map[string][]string) []reportRow {
+	rows := []reportRow{}
+	iterateOverCISortedMap(m, func(key string, values []string) {
+		rows = append(rows, reportRow{Key: key, Values: sortedCIUnique(values)})
+	})
+	return rows
+}
+
+// Return a sorted copy of a list of names, ignoring case when sorting.
+func sortedCI(values []string) []string {
+	sorted := append([]string{}, values...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return strings.ToLower(sorted[i]) < strings.ToLower(sorted[j])
+	})
+	return sorted
+}
+
+// Return a sorted, de-duplicated copy of a list of names, ignoring case when sorting.
+func sortedCIUnique(values []string) []string {
+	return dedup(sortedCI(values))
+}
+
+// Prepare a project for display in a document.
+func newReportProject(id int, pi *projectInformation) reportProject {
+	rp := reportProject{
+		ID:           id,
+		Path:         pi.path,
+		Format:       pi.format.String(),
+		BackupOf:     pi.backupOf,
+		Application:  pi.application,
+		Version:      pi.version,
+		Plugins:      reportRows(pi.pluginToTrackMap),
+		Tracks:       reportRows(pi.trackToPluginMap),
+		MissingMedia: pi.MissingMedia(),
+		Warnings:     pi.Warnings(),
+		Errors:       pi.Errors(),
+	}
+	for _, field := range pi.metadata.fields() {
+		rp.Metadata = append(rp.Metadata, reportField{Name: field.name, Value: field.value})
+	}
+	return rp
+}
+
+// Collects the results of a command for output in the chosen format. Projects rendered with a template are printed as each project is examined. Text is printed once all projects have been examined,
+// so that backups can follow the project that they are a copy of. Other formats are documents that are also written once all projects have been examined, and may include a summary of the library.
+type reportOutput struct {
+	outputOptions
+	libraryOnly bool // Whether documents include only the summary of the library, as the report command's do.
+	template    *template.Template
+	templateOut io.Writer // Where projects rendered with the template are written as they are examined.
+	templateErr error     // The first error encountered while rendering a project with the template.
+	projects    []*projectInformation
+	library     libraryInformation
+}
+
+// Create a reportOutput that writes with the given options. Returns an error if the options conflict or the template can't be parsed.
+func newReportOutput(options outputOptions) (*reportOutput, error) {
+	ro := &reportOutput{outputOptions: options, templateOut: os.Stdout, library: newLibraryInformation()}
+	if len(options.templatePath) != 0 {
+		if options.format != OF_Text {
+			return nil, errors.New("-template can't be used with -format")
+		}
+		var err error
+		if ro.template, err = parseOutputTemplate(options.templatePath); err != nil {
+			return nil, err
+		}
+	}
+	return ro, nil
+}
+
+// Add the results of examining a project. With a template, the project is printed straight away.
+func (ro *reportOutput) add(pi *projectInformation) {
+	ro.library.addProject(pi)
+	switch {
+	case ro.libraryOnly:
+	case ro.template != nil:
+		if err := ro.template.Execute(ro.templateOut, pi); err != nil && ro.templateErr == nil {
+			ro.templateErr = err
+		}
+	default:
+		ro.projects = append(ro.projects, pi)
+	}
+}
+
+// Return the collected projects sorted by path, with backups following the project that they are a copy of.
+func (ro *reportOutput) sortedProjects() []*projectInformation {
+	projects := append([]*projectInformation{}, ro.projects...)
+	sort.Slice(projects, func(i, j int) bool {
+		return projectPathLess(projects[i], projects[j])
+	})
+	return projects
+}
+
+// Prepare the collected results for display in a document, with the projects sorted by path and backups following the project that they are a copy of.
+func (ro *reportOutput) report() report {
+	projects := ro.sortedProjects()
+
+	r := report{ProjectCount: ro.library.projectCount, Summary: ro.summary || ro.libraryOnly}
+	for index, pi := range projects {
+		r.Projects = append(r.Projects, newReportProject(index+1, pi))
+	}
+	iterateOverCISortedMap(ro.library.pluginToProjectMap, func(plugin string, projects []string) {
+		r.Plugins = append(r.Plugins, reportPlugin{Name: plugin, Projects: sortedCIUnique(projects), TrackCount: ro.library.pluginTrackCounts[plugin]})
+	})
+	return r
+}
+
+// Write the collected results once all projects have been examined, or the summary of the library with a template. Returns an error if the document can't be written
+// or the template couldn't be rendered.
+func (ro *reportOutput) finish(w io.Writer) error {
+	if ro.template != nil {
+		if ro.templateErr != nil {
+			return ro.templateErr
+		}
+		if library := ro.template.Lookup(libraryTemplateName); library != nil {
+			return library.Execute(w, &ro.library)
+		}
+		if ro.libraryOnly {
+			// The report command renders the summary of the library with the main template if there isn't a library template.
+			return ro.template.Execute(w, &ro.library)
+		}
+		return nil
+	}
+
+	switch ro.format {
+	case OF_Text:
+		for _, pi := range ro.sortedProjects() {
+			if _, err := fmt.Fprint(w, pi.String()); err != nil {
+				return err
+			}
+		}
+	case OF_HTML:
+		return writeHTMLReport(w, ro.report())
+	case OF_Markdown:
+		return writeMarkdownReport(w, ro.report())
+	}
+	return nil
+}
+
+// Write the collected results to stdout once a command has examined all projects. Returns the command's exit code: [exitCode] if the document was written, or 1 if it wasn't.
+func (ro *reportOutput) finishCommand(exitCode int) int {
+	if err := ro.finish(os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "can't write the report:", err)
+		return 1
+	}
+	return exitCode
+}
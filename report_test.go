@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOutputFormatFlag(t *testing.T) {
+	var format outputFormat
+	if err := format.Set("HTML"); err != nil || format != OF_HTML {
+		t.Errorf("Expected html, got %s %v", format.String(), err)
+	}
+	if err := format.Set("pdf"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
+
+// Create a reportOutput with the given options.
+func newTestReportOutput(t *testing.T, options outputOptions) *reportOutput {
+	t.Helper()
+	output, err := newReportOutput(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return output
+}
+
+// Add two projects to a reportOutput, and return it.
+func addTestProjects(output *reportOutput) *reportOutput {
+	second := projectWithTracks("B/Second.cpr", map[string][]string{"Stereo Out": {"DSEQ3"}})
+	second.logError(newProjectError(EK_TruncatedChunk, errors.New("the chunk is short")))
+	first := projectWithTracks("A/First <Mix>.als", map[string][]string{
+		"Bass":   {"DUNE 3", "StandardCLIP"},
+		"Master": {"DSEQ3", "DSEQ3"},
+	})
+	first.metadata = projectMetadata{tempo: 124, numerator: 4, denominator: 4}
+	output.add(second)
+	output.add(first)
+	return output
+}
+
+func TestTextOutput(t *testing.T) {
+	output := newTestReportOutput(t, outputOptions{format: OF_Text})
+	backup := projectWithTracks("Songs/Mix-01.bak", map[string][]string{"Bass": {"DUNE 3"}})
+	backup.backupOf = "Songs/Mix.cpr"
+	// Projects are added in the order in which they finish being examined.
+	for _, pi := range []*projectInformation{
+		backup,
+		projectWithTracks("Songs/Zebra.cpr", map[string][]string{"Pad": {"VPS Avenger"}}),
+		projectWithTracks("Songs/Mix.cpr", map[string][]string{"Bass": {"DUNE 3"}}),
+		projectWithTracks("Songs/Ambient.cpr", map[string][]string{"Pad": {"VPS Avenger"}}),
+	} {
+		output.add(pi)
+	}
+
+	var b bytes.Buffer
+	if err := output.finish(&b); err != nil {
+		t.Fatal(err)
+	}
+	paths := []string{}
+	for _, line := range strings.Split(b.String(), "\n") {
+		if path, found := strings.CutPrefix(line, "Project: "); found {
+			paths = append(paths, path)
+		}
+	}
+	if expected := []string{"Songs/Ambient.cpr", "Songs/Mix.cpr", "Songs/Mix-01.bak", "Songs/Zebra.cpr"}; strings.Join(paths, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected the projects to be sorted with backups after their project, got %q", paths)
+	}
+}
+
+func TestReport(t *testing.T) {
+	r := addTestProjects(newTestReportOutput(t, outputOptions{format: OF_HTML, summary: true})).report()
+
+	if len(r.Projects) != 2 || r.Projects[0].Path != "A/First <Mix>.als" || r.Projects[0].ID != 1 {
+		t.Fatalf("Expected the projects to be sorted by path, got %+v", r.Projects)
+	}
+	first := r.Projects[0]
+	if len(first.Plugins) != 3 || first.Plugins[0].Key != "DSEQ3" || strings.Join(first.Plugins[0].Values, ",") != "Master" {
+		t.Errorf("Expected de-duplicated plugin rows, got %+v", first.Plugins)
+	}
+	if len(first.Metadata) != 2 || first.Metadata[0] != (reportField{"Tempo", "124 BPM"}) {
+		t.Errorf("Unexpected metadata %+v", first.Metadata)
+	}
+	if len(r.Projects[1].Errors) != 1 {
+		t.Errorf("Expected an error, got %v", r.Projects[1].Errors)
+	}
+	if r.ProjectCount != 2 || len(r.Plugins) != 3 || r.Plugins[0].Name != "DSEQ3" || len(r.Plugins[0].Projects) != 2 || r.Plugins[0].TrackCount != 2 {
+		t.Errorf("Unexpected library summary %+v", r.Plugins)
+	}
+
+	output := newTestReportOutput(t, outputOptions{format: OF_HTML, summary: true})
+	output.libraryOnly = true
+	if r := addTestProjects(output).report(); len(r.Projects) != 0 || len(r.Plugins) != 3 {
+		t.Errorf("Expected only the library summary, got %+v", r)
+	}
+}
+
+func TestHTMLReport(t *testing.T) {
+	var b bytes.Buffer
+	if err := addTestProjects(newTestReportOutput(t, outputOptions{format: OF_HTML, summary: true})).finish(&b); err != nil {
+		t.Fatal(err)
+	}
+	html := b.String()
+
+	for _, expected := range []string{
+		"<!DOCTYPE html>",
+		`<h2 id="project-1">A/First &lt;Mix&gt;.als</h2>`,
+		"<dt>Tempo</dt><dd>124 BPM</dd>",
+		`<table id="project-1-plugins" class="sortable">`,
+		"<tr><td>Bass</td><td>DUNE 3, StandardCLIP</td></tr>",
+		`<tr><td>DSEQ3</td><td class="number">2</td><td class="number">2</td><td>A/First &lt;Mix&gt;.als, B/Second.cpr</td></tr>`,
+		"the chunk is short",
+		"<script>",
+	} {
+		if !strings.Contains(html, expected) {
+			t.Errorf("Expected the report to contain %q", expected)
+		}
+	}
+	// The document must not refer to anything that would need a network connection.
+	for _, unexpected := range []string{"<Mix>", "http://", "https://", "<link", "src="} {
+		if strings.Contains(html, unexpected) {
+			t.Errorf("Expected the report not to contain %q", unexpected)
+		}
+	}
+}
+
+func TestMarkdownReport(t *testing.T) {
+	var b bytes.Buffer
+	if err := addTestProjects(newTestReportOutput(t, outputOptions{format: OF_Markdown, summary: true})).finish(&b); err != nil {
+		t.Fatal(err)
+	}
+	markdown := b.String()
+
+	for _, expected := range []string{
+		"# Plugin report\n\n2 project(s), 3 plugin(s).\n\n## A/First \\<Mix\\>.als\n\n- **Version:** \\<unknown version\\>\n- **Tempo:** 124 BPM\n",
+		"### Plugins\n\n| Plugin | Tracks |\n| --- | --- |\n| DSEQ3 | Master |\n",
+		"| Bass | DUNE 3, StandardCLIP |\n",
+		"### Errors\n\n- truncated chunk: the chunk is short\n",
+		"## Library\n\n| Plugin | Projects | Tracks | Used in |\n| --- | --- | --- | --- |\n| DSEQ3 | 2 | 2 | A/First \\<Mix\\>.als, B/Second.cpr |\n",
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("Expected the report to contain %q, got:\n%s", expected, markdown)
+		}
+	}
+
+	if escaped := escapeMarkdown(`C:\Sets\a|b_*.als`); escaped != `C:\\Sets\\a\|b\_\*.als` {
+		t.Errorf("Unexpected escaping %q", escaped)
+	}
+
+	// The library summary can be left out.
+	b.Reset()
+	addTestProjects(newTestReportOutput(t, outputOptions{format: OF_Markdown})).finish(&b)
+	if strings.Contains(b.String(), "## Library") {
+		t.Errorf("Expected no library summary, got:\n%s", b.String())
+	}
+	b.Reset()
+	addTestProjects(newTestReportOutput(t, outputOptions{format: OF_HTML})).finish(&b)
+	if strings.Contains(b.String(), "<h2>Library</h2>") {
+		t.Error("Expected no library summary in the HTML report")
+	}
+}
+
+const testTemplate = `{{.Path}} ({{.Version}}, {{.Tempo}} BPM)
+{{range .Tracks}}  {{pad 8 .}}{{join ($.PluginsOnTrack .) ", "}}
+{{end}}{{range .Errors}}  error: {{.}}
+{{end}}
+{{- define "library"}}{{.ProjectCount}} projects
+{{range .Plugins}}{{upper .}}: {{len ($.ProjectsForPlugin .)}} project(s), {{$.TrackCount .}} track(s)
+{{end}}{{end}}`
+
+func TestTemplateOutput(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTestFile(t, filepath.Join(dir, "report.tmpl"), []byte(testTemplate))
+
+	var b bytes.Buffer
+	output := newTestReportOutput(t, outputOptions{templatePath: path})
+	output.templateOut = &b
+	addTestProjects(output)
+	if err := output.finish(&b); err != nil {
+		t.Fatal(err)
+	}
+	expected := `B/Second.cpr (<unknown version>, 0 BPM)
+  Stereo OutDSEQ3
+  error: truncated chunk: the chunk is short
+A/First <Mix>.als (<unknown version>, 124 BPM)
+  Bass    DUNE 3, StandardCLIP
+  Master  DSEQ3
+2 projects
+DSEQ3: 2 project(s), 2 track(s)
+DUNE 3: 1 project(s), 1 track(s)
+STANDARDCLIP: 1 project(s), 1 track(s)
+`
+	if b.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, b.String())
+	}
+
+	// Without a library template, the report command renders the library with the main template.
+	libraryPath := writeTestFile(t, filepath.Join(dir, "library.tmpl"), []byte(`{{join .Plugins "|"}}`))
+	b.Reset()
+	output = newTestReportOutput(t, outputOptions{templatePath: libraryPath})
+	output.libraryOnly = true
+	if err := addTestProjects(output).finish(&b); err != nil || b.String() != "DSEQ3|DUNE 3|StandardCLIP" {
+		t.Errorf("Unexpected library output %q %v", b.String(), err)
+	}
+
+	// Errors that occur while rendering projects are reported when the output is finished.
+	badPath := writeTestFile(t, filepath.Join(dir, "bad.tmpl"), []byte(`{{.NoSuchField}}`))
+	output = newTestReportOutput(t, outputOptions{templatePath: badPath})
+	output.templateOut = &b
+	if err := addTestProjects(output).finish(&b); err == nil {
+		t.Error("Expected an error rendering a field that doesn't exist")
+	}
+
+	if _, err := newReportOutput(outputOptions{templatePath: writeTestFile(t, filepath.Join(dir, "unclosed.tmpl"), []byte(`{{.Path`))}); err == nil {
+		t.Error("Expected an error parsing a malformed template")
+	}
+	if _, err := newReportOutput(outputOptions{format: OF_HTML, templatePath: path}); err == nil {
+		t.Error("Expected an error using a template with a document format")
+	}
+}
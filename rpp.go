@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const rppExtension = ".rpp"
+
+// The first token of a Reaper project file.
+const rppMagic = "<REAPER_PROJECT"
+
+// The parser for Reaper projects, which are text files that begin with <REAPER_PROJECT.
+type rppParser struct{}
+
+var PF_RPP = registerProjectFormat(rppParser{})
+
+func (rppParser) Name() string { return "RPP" }
+
+func (rppParser) Extensions() []string { return []string{rppExtension} }
+
+func (rppParser) Sniff(header []byte, content io.ReaderAt, size int64) bool {
+	return bytes.HasPrefix(trimTextHeader(header), []byte(rppMagic))
+}
+
+func (rppParser) Examine(path string) *projectInformation {
+	return examineRPP(path)
+}
+
+// Split a line of a Reaper project file into tokens. Tokens are separated by whitespace and may be enclosed in double quotes, single quotes or backticks.
+func splitRPPLine(line string) []string {
+	tokens := []string{}
+	line = strings.TrimSpace(line)
+	for len(line) != 0 {
+		var token string
+		if quote := line[0]; quote == '"' || quote == '\'' || quote == '`' {
+			if end := strings.IndexByte(line[1:], quote); end >= 0 {
+				token, line = line[1:end+1], line[end+2:]
+			} else {
+				token, line = line[1:], ""
+			}
+		} else if end := strings.IndexAny(line, " \t"); end >= 0 {
+			token, line = line[:end], line[end:]
+		} else {
+			token, line = line, ""
+		}
+		tokens = append(tokens, token)
+		line = strings.TrimLeft(line, " \t")
+	}
+	return tokens
+}
+
+// Matches the description of a plugin in an FX chain, such as "VST3: Pro-Q 3 (FabFilter)", capturing the name and the vendor.
+var rppPluginPattern = regexp.MustCompile(`^(?:(?:VST3?|AU|CLAP|LV2|DX)i?: )?(.*?)(?: \(([^()]*)\))?$`)
+
+// Parse the description of a plugin in an FX chain into its name and vendor.
+func parseRPPPluginDescription(description string) (string, string) {
+	match := rppPluginPattern.FindStringSubmatch(description)
+	if match == nil || len(match[1]) == 0 {
+		return description, ""
+	}
+	return match[1], match[2]
+}
+
+// A plugin found in a Reaper project.
+type rppPlugin struct {
+	name   string
+	vendor string
+}
+
+// Examine the contents of a Reaper project file to obtain version information and a mapping of track names to plugin names.
+// Plugins in a track's FX chain, input FX chain and take FX are all associated with the track. Plugins in the master FX chain are associated with a track named Master.
+func examineRPP(path string) *projectInformation {
+	info := newProjectInformation(path)
+
+	file, _, err := openProjectFile(path)
+	if err != nil {
+		info.logError(newProjectError(EK_IO, err))
+		return &info
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	elements := []string{}
+	trackNumber := 0
+	trackName := ""
+	trackPlugins := []rppPlugin{}
+	itemPosition, itemLength, end := 0.0, 0.0, 0.0
+	offset := 0
+
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := reader.ReadString('\n')
+		lineOffset := offset
+		offset += len(line)
+		if err != nil && err != io.EOF {
+			info.logError(newProjectErrorAt(EK_IO, "", lineOffset, err))
+			return &info
+		}
+		if len(line) == 0 && err == io.EOF {
+			break
+		}
+
+		if lineNumber == 1 {
+			line = strings.TrimPrefix(line, "\xef\xbb\xbf")
+		}
+		tokens := splitRPPLine(line)
+		if lineNumber == 1 {
+			if len(tokens) == 0 || tokens[0] != rppMagic {
+				info.logError(newProjectErrorAt(EK_BadHeader, "", 0, fmt.Errorf("the file doesn't begin with %s", rppMagic)))
+				return &info
+			}
+			info.application = "REAPER"
+			if len(tokens) > 2 {
+				info.version, _, _ = strings.Cut(tokens[2], "/")
+			}
+		}
+
+		switch {
+		case len(tokens) == 0:
+		case tokens[0] == ">":
+			if len(elements) == 0 {
+				info.logError(newProjectErrorAt(EK_MalformedText, "", lineOffset, fmt.Errorf("unexpected '>' on line %d", lineNumber)))
+				return &info
+			}
+			if elements[len(elements)-1] == "ITEM" {
+				end = max(end, itemPosition+itemLength)
+				itemPosition, itemLength = 0, 0
+			}
+			if elements[len(elements)-1] == "TRACK" {
+				trackNumber++
+				if len(trackName) == 0 {
+					trackName = fmt.Sprintf("Track %d", trackNumber)
+				}
+				for _, plugin := range trackPlugins {
+					info.mapTrackToPlugin(plugin.name, trackName)
+					info.setPluginVendor(plugin.name, plugin.vendor)
+				}
+				trackName, trackPlugins = "", []rppPlugin{}
+			}
+			elements = elements[:len(elements)-1]
+		case strings.HasPrefix(tokens[0], "<"):
+			element := tokens[0][1:]
+			if (element == "VST" || element == "AU" || element == "CLAP" || element == "JS" || element == "LV2" || element == "DX") && len(tokens) > 1 {
+				name, vendor := tokens[1], ""
+				if element != "JS" {
+					name, vendor = parseRPPPluginDescription(tokens[1])
+				}
+				if slices.Contains(elements, "MASTERFXLIST") {
+					info.mapTrackToPlugin(name, "Master")
+					info.setPluginVendor(name, vendor)
+				} else if slices.Contains(elements, "TRACK") {
+					trackPlugins = append(trackPlugins, rppPlugin{name: name, vendor: vendor})
+				}
+			}
+			elements = append(elements, element)
+		case tokens[0] == "NAME" && len(elements) != 0 && elements[len(elements)-1] == "TRACK" && len(tokens) > 1:
+			trackName = tokens[1]
+		case len(elements) == 1 && len(tokens) > 1:
+			// Project settings are properties of the REAPER_PROJECT element. The tempo is followed by the time signature.
+			switch tokens[0] {
+			case "TEMPO":
+				info.metadata.tempo, _ = strconv.ParseFloat(tokens[1], 64)
+				if len(tokens) > 3 {
+					info.metadata.numerator, _ = strconv.Atoi(tokens[2])
+					info.metadata.denominator, _ = strconv.Atoi(tokens[3])
+				}
+			case "SAMPLERATE":
+				info.metadata.sampleRate, _ = strconv.Atoi(tokens[1])
+			}
+		case len(elements) != 0 && elements[len(elements)-1] == "ITEM" && len(tokens) > 1:
+			// Item positions and lengths are measured in seconds.
+			switch tokens[0] {
+			case "POSITION":
+				itemPosition, _ = strconv.ParseFloat(tokens[1], 64)
+			case "LENGTH":
+				itemLength, _ = strconv.ParseFloat(tokens[1], 64)
+			}
+		}
+
+		if err == io.EOF {
+			break
+		}
+	}
+
+	info.metadata.length = secondsToDuration(end)
+
+	if len(elements) != 0 {
+		info.logError(newProjectErrorAt(EK_TruncatedChunk, elements[len(elements)-1], offset, fmt.Errorf("the file ended before the %s element was closed", elements[len(elements)-1])))
+	}
+
+	return &info
+}
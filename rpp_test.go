@@ -0,0 +1,93 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testRPP = `<REAPER_PROJECT 0.1 "7.11/win64" 1712345678
+  RIPPLE 0
+  <MASTERFXLIST
+    <VST "VST: Pro-L 2 (FabFilter)" "Pro-L 2.dll" 0 "" 1181306956<56535446464C327072>
+      bWFzdGVy
+    >
+  >
+  <TRACK {2F5D3C5A-1111-4A4A-9F9F-000000000001}
+    NAME "Lead Vox"
+    <FXCHAIN
+      SHOW 0
+      <VST "VST3: Pro-Q 3 (FabFilter)" "FabFilter Pro-Q 3.vst3" 0 "" 1
+        cHJvcTM=
+      >
+      <JS loser/3BandEQ ""
+      >
+      <CLAP "CLAPi: Surge XT (Surge Synth Team)" org.surge-synth-team.surge-xt
+      >
+    >
+  >
+  <TRACK {2F5D3C5A-1111-4A4A-9F9F-000000000002}
+    NAME ""
+    <FXCHAIN
+      <AU "AU: AUDelay (Apple)" "Apple: AUDelay" "" 0
+      >
+    >
+  >
+>
+`
+
+func TestExamineRPP(t *testing.T) {
+	path := writeTestFile(t, filepath.Join(t.TempDir(), "Mix.rpp"), []byte(testRPP))
+
+	if format := chooseProjectFormat(path); format != PF_RPP {
+		t.Fatalf("Expected an RPP file, got %s", format)
+	}
+
+	pi := examineProject(path)
+	if pi.failed() {
+		t.Fatalf("Unexpected errors: %v", pi.errors)
+	}
+	if pi.application != "REAPER" || pi.version != "7.11" {
+		t.Errorf("Expected REAPER 7.11, got %q %q", pi.application, pi.version)
+	}
+	expected := map[string][]string{
+		"Master":   {"Pro-L 2"},
+		"Lead Vox": {"Pro-Q 3", "loser/3BandEQ", "Surge XT"},
+		"Track 2":  {"AUDelay"},
+	}
+	if !reflect.DeepEqual(pi.trackToPluginMap, expected) {
+		t.Errorf("Expected %v, got %v", expected, pi.trackToPluginMap)
+	}
+	for plugin, vendor := range map[string]string{"Pro-Q 3": "FabFilter", "Surge XT": "Surge Synth Team", "AUDelay": "Apple", "loser/3BandEQ": ""} {
+		if pi.pluginVendors[plugin] != vendor {
+			t.Errorf("Expected vendor %q for %s, got %q", vendor, plugin, pi.pluginVendors[plugin])
+		}
+	}
+}
+
+func TestExamineRPPErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	pi := examineRPP(writeTestFile(t, filepath.Join(dir, "NotReaper.rpp"), []byte("<SOMETHING_ELSE\n>\n")))
+	if len(pi.errors) != 1 || pi.errors[0].kind != EK_BadHeader {
+		t.Errorf("Expected a bad header error, got %v", pi.errors)
+	}
+
+	pi = examineRPP(writeTestFile(t, filepath.Join(dir, "Truncated.rpp"), []byte(testRPP[:len(testRPP)/2])))
+	if len(pi.errors) != 1 || pi.errors[0].kind != EK_TruncatedChunk {
+		t.Errorf("Expected a truncated chunk error, got %v", pi.errors)
+	}
+
+	pi = examineRPP(writeTestFile(t, filepath.Join(dir, "Unbalanced.rpp"), []byte("<REAPER_PROJECT 0.1 \"6.0/x64\" 0\n>\n>\n")))
+	if len(pi.errors) != 1 || pi.errors[0].kind != EK_MalformedText {
+		t.Errorf("Expected a malformed text error, got %v", pi.errors)
+	}
+}
+
+func TestSplitRPPLine(t *testing.T) {
+	tokens := splitRPPLine(`  <VST "VST: Pro-Q 3 (FabFilter)" 'it''s' ` + "`a \"b\"`" + ` 0`)
+	expected := []string{"<VST", "VST: Pro-Q 3 (FabFilter)", "it", "s", `a "b"`, "0"}
+	if !reflect.DeepEqual(tokens, expected) {
+		t.Errorf("Expected %q, got %q", expected, tokens)
+	}
+}
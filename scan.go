@@ -0,0 +1,177 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/MrSplidge/go-coutil"
+)
+
+// The default maximum time to spend examining a single project file.
+const defaultTimeout = time.Minute
+
+// Options that control how project files are found and examined. They are shared by the commands that scan folders.
+type scanOptions struct {
+	numThreads      int
+	timeout         time.Duration
+	stats           bool
+	foldersToIgnore stringFlags
+	extensions      stringFlags
+}
+
+// Register the scan options with a command's flag set.
+func (so *scanOptions) register(fs *flag.FlagSet) {
+	so.registerWorkerFlags(fs)
+	fs.Var(&so.foldersToIgnore, "ignore-folders", "A semicolon-separated list of folders to ignore when traversing the hierarchy.")
+	fs.Var(&so.extensions, "extensions", "A semicolon-separated list of project file extensions to include when traversing the hierarchy (default "+strings.Join(projectExtensions(), ";")+").")
+}
+
+// Register only the options that control how project files are examined with a command's flag set.
+func (so *scanOptions) registerWorkerFlags(fs *flag.FlagSet) {
+	fs.IntVar(&so.numThreads, "num-threads", runtime.NumCPU(), "The number of worker threads to use.")
+	fs.DurationVar(&so.timeout, "timeout", defaultTimeout, "The maximum time to spend examining a single project file (0 for no limit).")
+	fs.BoolVar(&so.stats, "stats", true, "Show live progress (when stderr is a terminal) and final run statistics on stderr.")
+}
+
+// Examine a single project file, choosing a parser based on its content, or on its file extension if the content isn't recognised.
+// Returns nil if the file isn't a supported project.
+func examineProject(path string) *projectInformation {
+	format := chooseProjectFormat(path)
+	parser := format.parser()
+	if parser == nil {
+		return nil
+	}
+
+	// Parsers may refine the format, as the Cubase parser does for Nuendo projects.
+	pi := parser.Examine(path)
+	if pi == nil {
+		return nil
+	}
+	if pi.format == PF_Unknown {
+		pi.format = format
+	}
+	return pi
+}
+
+// Find the project files within [paths], examine them using a pool of worker threads, and pass each result to [processResult].
+// A summary of any projects that could not be examined fully is printed to stderr at the end. Returns the process exit code, which is 1 if any project failed.
+func (so *scanOptions) scan(paths []string, processResult func(pi *projectInformation)) int {
+	return so.examine(so.find(paths), processResult)
+}
+
+// Find the project files within [paths], skipping ignored folders and files without one of the chosen extensions.
+func (so *scanOptions) find(paths []string) []string {
+	extensions := so.extensions
+	if len(extensions) == 0 {
+		extensions = projectExtensions()
+	}
+
+	return scanAndFilterPaths(paths,
+		// Exclude certain folders.
+		func(basename, fullPath string) bool {
+			return !slices.Contains(so.foldersToIgnore, basename)
+		},
+		// Include files with certain file extensions.
+		func(basename, fullPath string) bool {
+			return slices.Contains(extensions, filepath.Ext(basename))
+		})
+}
+
+// Examine the project files in [projectPaths] using a pool of worker threads, and pass each result to [processResult].
+// A summary of any projects that could not be examined fully is printed to stderr at the end. Returns the process exit code, which is 1 if any project failed.
+func (so *scanOptions) examine(projectPaths []string, processResult func(pi *projectInformation)) int {
+	defer closeArchives()
+	failedPaths := []string{}
+
+	stats := newRunStatistics(projectPaths)
+	stats.live = stats.live && so.stats
+	stats.start()
+
+	coutil.WorkPool(
+		max(1, so.numThreads),
+		// Work items to process.
+		projectPaths,
+		// Work item processor.
+		func(path string) *projectInformation {
+			stats.begin(path)
+			pi := examineIsolated(path, so.timeout, examineProject)
+			if pi == nil {
+				stats.skip(path)
+			}
+			return pi
+		},
+		// Results processor.
+		func(pi *projectInformation) {
+			if pi != nil {
+				stats.withoutProgress(func() {
+					processResult(pi)
+				})
+				if pi.failed() {
+					failedPaths = append(failedPaths, pi.path+" ("+pi.errors[0].kind.String()+")")
+				}
+				stats.record(pi)
+			}
+		})
+	stats.stop()
+
+	// The summary goes to stderr so that it doesn't interfere with the output of commands that are designed to be piped.
+	if len(failedPaths) != 0 {
+		slices.Sort(failedPaths)
+		fmt.Fprintf(os.Stderr, "%d project(s) could not be examined fully:\n", len(failedPaths))
+		for _, path := range failedPaths {
+			fmt.Fprintln(os.Stderr, " ", path)
+		}
+	}
+
+	if so.stats {
+		stats.summarize()
+	}
+
+	// Allow scripts to detect that at least one project failed.
+	if len(failedPaths) != 0 {
+		return 1
+	}
+	return 0
+}
+
+// Run the scan command, which prints the plugin to track and track to plugin mappings of each project.
+func runScan(args []string) int {
+	fs := newCommandFlagSet("scan", "[flags] <file|folder> [<file|folder> ...]")
+	var options scanOptions
+	options.register(fs)
+	var formatOptions outputOptions
+	formatOptions.register(fs)
+	var listFormatsFlag = fs.Bool("list-formats", false, "List the supported project formats and their extensions, and exit.")
+	fs.Parse(args)
+
+	if *listFormatsFlag {
+		printProjectFormats(os.Stdout)
+		return 0
+	}
+
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return 2
+	}
+
+	output, err := newReportOutput(formatOptions)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	if formatOptions.format == OF_Text && output.template == nil {
+		fmt.Printf("Using %d threads.\n", options.numThreads)
+		fmt.Println("Ignoring these folders:", options.foldersToIgnore)
+		fmt.Println("Scanning these items:", fs.Args())
+		fmt.Println()
+	}
+
+	return output.finishCommand(options.scan(fs.Args(), output.add))
+}
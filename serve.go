@@ -0,0 +1,461 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// The default address on which the serve command listens. Only the local machine can connect unless another address is given.
+const defaultServeAddress = "localhost:8080"
+
+// The default interval at which the serve command looks for new, changed and deleted project files.
+const defaultRefreshInterval = time.Minute
+
+// Limits on how long the server waits for clients, so that slow or idle connections don't hold resources indefinitely.
+const (
+	serveReadHeaderTimeout = 10 * time.Second
+	serveReadTimeout       = 30 * time.Second
+	serveWriteTimeout      = time.Minute
+	serveIdleTimeout       = 2 * time.Minute
+)
+
+// A project held in memory by the server, with the modification time and size of its file when it was examined.
+type servedProject struct {
+	id      string
+	info    *projectInformation
+	modTime time.Time
+	size    int64
+}
+
+// The modification time and size of a file, which are used to tell whether it has changed since it was examined.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// A server that keeps the results of examining the projects within some folders in memory, and answers questions about them over HTTP.
+type projectServer struct {
+	options  scanOptions
+	paths    []string // The files and folders to examine.
+	mutex    sync.RWMutex
+	projects map[string]*servedProject // Keyed by path.
+	ignored  map[string]fileStamp      // Files that were examined but aren't supported projects, keyed by path, so that they aren't examined again until they change.
+}
+
+// Create a projectServer for the projects within [paths]. Projects aren't examined until the first refresh.
+func newProjectServer(options scanOptions, paths []string) *projectServer {
+	return &projectServer{options: options, paths: paths, projects: map[string]*servedProject{}, ignored: map[string]fileStamp{}}
+}
+
+// Return a stable identifier for a project, derived from its path, for use in URLs.
+func projectID(path string) string {
+	sum := sha1.Sum([]byte(path))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Return the modification time and size of the file on disk that holds a project. For projects within archives, this is the outermost archive.
+func projectFileStamp(path string) (time.Time, int64, error) {
+	fileInfo, err := os.Stat(outermostFile(path))
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	return fileInfo.ModTime(), fileInfo.Size(), nil
+}
+
+// Find the project files, examine the ones that are new or have changed since they were last examined, and forget the ones that have gone.
+// Returns the number of files that were examined, including any that turned out not to be supported projects.
+func (ps *projectServer) refresh() int {
+	found := map[string]fileStamp{}
+	changed := []string{}
+
+	ps.mutex.RLock()
+	for _, path := range ps.options.find(ps.paths) {
+		modTime, size, err := projectFileStamp(path)
+		if err != nil {
+			continue
+		}
+		found[path] = fileStamp{modTime, size}
+		if project, known := ps.projects[path]; known && project.modTime.Equal(modTime) && project.size == size {
+			continue
+		}
+		if ignored, known := ps.ignored[path]; known && ignored.modTime.Equal(modTime) && ignored.size == size {
+			continue
+		}
+		changed = append(changed, path)
+	}
+	ps.mutex.RUnlock()
+
+	// Projects are examined without holding the lock, so that requests can be answered with the previous results in the meantime.
+	examined := []*projectInformation{}
+	ps.options.examine(changed, func(pi *projectInformation) {
+		examined = append(examined, pi)
+	})
+
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+	for path := range ps.projects {
+		if _, exists := found[path]; !exists {
+			delete(ps.projects, path)
+		}
+	}
+	for path := range ps.ignored {
+		if _, exists := found[path]; !exists {
+			delete(ps.ignored, path)
+		}
+	}
+	// Files that examine didn't return a result for aren't supported projects, such as unrecognised XML files.
+	for _, path := range changed {
+		delete(ps.projects, path)
+		ps.ignored[path] = found[path]
+	}
+	for _, pi := range examined {
+		stamp := found[pi.path]
+		delete(ps.ignored, pi.path)
+		ps.projects[pi.path] = &servedProject{id: projectID(pi.path), info: pi, modTime: stamp.modTime, size: stamp.size}
+	}
+	return len(changed)
+}
+
+// Return the projects held by the server, sorted by path with backups following their projects. The caller must hold the lock.
+func (ps *projectServer) sortedProjects() []*servedProject {
+	projects := []*servedProject{}
+	for _, project := range ps.projects {
+		projects = append(projects, project)
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		return projectPathLess(projects[i].info, projects[j].info)
+	})
+	return projects
+}
+
+// A summary of a project, as listed by /projects.
+type projectSummaryJSON struct {
+	ID          string `json:"id"`
+	Path        string `json:"path"`
+	Format      string `json:"format"`
+	Application string `json:"application,omitempty"`
+	Version     string `json:"version"`
+	Plugins     int    `json:"plugins"`
+	Tracks      int    `json:"tracks"`
+	Errors      int    `json:"errors"`
+}
+
+// The details of a project, as returned by /projects/{id}.
+type projectJSON struct {
+	projectSummaryJSON
+	BackupOf      string              `json:"backupOf,omitempty"`
+	Creator       string              `json:"creator,omitempty"`
+	Tempo         float64             `json:"tempo,omitempty"`
+	TimeSignature string              `json:"timeSignature,omitempty"`
+	Length        float64             `json:"length,omitempty"` // In seconds.
+	SampleRate    int                 `json:"sampleRate,omitempty"`
+	BitDepth      int                 `json:"bitDepth,omitempty"`
+	PluginTracks  map[string][]string `json:"pluginTracks"`
+	TrackPlugins  map[string][]string `json:"trackPlugins"`
+	Vendors       map[string]string   `json:"vendors"`
+	MissingMedia  []string            `json:"missingMedia"`
+	Warnings      []string            `json:"warnings"`
+	ErrorMessages []string            `json:"errorMessages"`
+}
+
+// A plugin, as listed by /plugins.
+type pluginSummaryJSON struct {
+	Name     string `json:"name"`
+	Projects int    `json:"projects"`
+	Tracks   int    `json:"tracks"`
+}
+
+// A project that uses a plugin, as listed by /plugins/{name}/projects.
+type pluginProjectJSON struct {
+	ID     string   `json:"id"`
+	Path   string   `json:"path"`
+	Tracks []string `json:"tracks"`
+}
+
+// Summarize a project for /projects.
+func (sp *servedProject) summaryJSON() projectSummaryJSON {
+	return projectSummaryJSON{
+		ID:          sp.id,
+		Path:        sp.info.path,
+		Format:      sp.info.Format(),
+		Application: sp.info.application,
+		Version:     sp.info.version,
+		Plugins:     len(sp.info.pluginToTrackMap),
+		Tracks:      len(sp.info.trackToPluginMap),
+		Errors:      len(sp.info.errors),
+	}
+}
+
+// Describe a project in full for /projects/{id}.
+func (sp *servedProject) detailJSON() projectJSON {
+	pi := sp.info
+	detail := projectJSON{
+		projectSummaryJSON: sp.summaryJSON(),
+		BackupOf:           pi.backupOf,
+		Creator:            pi.metadata.creator,
+		Tempo:              pi.metadata.tempo,
+		TimeSignature:      pi.metadata.timeSignature(),
+		Length:             pi.metadata.length.Seconds(),
+		SampleRate:         pi.metadata.sampleRate,
+		BitDepth:           pi.metadata.bitDepth,
+		PluginTracks:       map[string][]string{},
+		TrackPlugins:       map[string][]string{},
+		Vendors:            pi.pluginVendors,
+		MissingMedia:       pi.MissingMedia(),
+		Warnings:           pi.Warnings(),
+		ErrorMessages:      pi.Errors(),
+	}
+	for plugin, tracks := range pi.pluginToTrackMap {
+		detail.PluginTracks[plugin] = sortedCIUnique(tracks)
+	}
+	for track, plugins := range pi.trackToPluginMap {
+		detail.TrackPlugins[track] = sortedCIUnique(plugins)
+	}
+	return detail
+}
+
+// Write a value as a JSON response.
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(value)
+}
+
+// Write an error as a JSON response.
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// List the projects.
+func (ps *projectServer) handleProjects(w http.ResponseWriter, r *http.Request) {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	summaries := []projectSummaryJSON{}
+	for _, project := range ps.sortedProjects() {
+		summaries = append(summaries, project.summaryJSON())
+	}
+	writeJSON(w, http.StatusOK, summaries)
+}
+
+// Describe a project.
+func (ps *projectServer) handleProject(w http.ResponseWriter, r *http.Request) {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	id := r.PathValue("id")
+	for _, project := range ps.projects {
+		if project.id == id {
+			writeJSON(w, http.StatusOK, project.detailJSON())
+			return
+		}
+	}
+	writeJSONError(w, http.StatusNotFound, fmt.Sprintf("no project has the id %q", id))
+}
+
+// List the plugins used across all projects.
+func (ps *projectServer) handlePlugins(w http.ResponseWriter, r *http.Request) {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	library := newLibraryInformation()
+	for _, project := range ps.projects {
+		library.addProject(project.info)
+	}
+	plugins := []pluginSummaryJSON{}
+	for _, plugin := range library.Plugins() {
+		plugins = append(plugins, pluginSummaryJSON{Name: plugin, Projects: len(library.ProjectsForPlugin(plugin)), Tracks: library.TrackCount(plugin)})
+	}
+	writeJSON(w, http.StatusOK, plugins)
+}
+
+// List the projects that use a plugin, with the tracks on which they use it.
+func (ps *projectServer) handlePluginProjects(w http.ResponseWriter, r *http.Request) {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+	name := r.PathValue("name")
+	projects := []pluginProjectJSON{}
+	for _, project := range ps.sortedProjects() {
+		if tracks, found := project.info.pluginToTrackMap[name]; found {
+			projects = append(projects, pluginProjectJSON{ID: project.id, Path: project.info.path, Tracks: sortedCIUnique(tracks)})
+		}
+	}
+	if len(projects) == 0 {
+		writeJSONError(w, http.StatusNotFound, fmt.Sprintf("no project uses the plugin %q", name))
+		return
+	}
+	writeJSON(w, http.StatusOK, projects)
+}
+
+// Serve the page that browses the API.
+func (ps *projectServer) handleIndex(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	serveIndexTemplate.Execute(w, nil)
+}
+
+// Return a handler for the server's API and browser page.
+func (ps *projectServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", ps.handleIndex)
+	mux.HandleFunc("GET /projects", ps.handleProjects)
+	mux.HandleFunc("GET /projects/{id}", ps.handleProject)
+	mux.HandleFunc("GET /plugins", ps.handlePlugins)
+	mux.HandleFunc("GET /plugins/{name}/projects", ps.handlePluginProjects)
+	return mux
+}
+
+// A page that lists the plugins and projects using the JSON API. Choosing a plugin lists the projects that use it, and choosing a project shows its tracks and plugins.
+var serveIndexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Plugins</title>
+<style>
+body { font-family: system-ui, -apple-system, "Segoe UI", sans-serif; margin: 0; display: grid; grid-template-columns: 22em 1fr; height: 100vh; color: #222; }
+nav { border-right: 1px solid #ccc; overflow: auto; padding: 1em; }
+main { overflow: auto; padding: 1em 2em; }
+input { width: 100%; box-sizing: border-box; padding: 0.3em; }
+ul { list-style: none; padding: 0; }
+li a { cursor: pointer; color: #0645ad; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ddd; padding: 0.3em 0.6em; text-align: left; vertical-align: top; }
+.error { color: #b00020; }
+</style>
+</head>
+<body>
+<nav>
+<input id="filter" type="search" placeholder="Filter plugins">
+<ul id="plugins"></ul>
+</nav>
+<main id="main"><p>Choose a plugin to list the projects that use it.</p></main>
+<script>
+function element(name, text) {
+	var node = document.createElement(name);
+	if (text !== undefined) { node.textContent = text; }
+	return node;
+}
+
+function link(text, onclick) {
+	var a = element("a", text);
+	a.addEventListener("click", onclick);
+	return a;
+}
+
+function fetchJSON(url) {
+	return fetch(url).then(function (response) { return response.json(); });
+}
+
+function showPlugin(name) {
+	fetchJSON("/plugins/" + encodeURIComponent(name) + "/projects").then(function (projects) {
+		var main = document.getElementById("main");
+		main.replaceChildren(element("h1", name));
+		var table = element("table");
+		table.appendChild(element("tr")).append(element("th", "Project"), element("th", "Tracks"));
+		projects.forEach(function (project) {
+			var row = table.appendChild(element("tr"));
+			row.appendChild(element("td")).appendChild(link(project.path, function () { showProject(project.id); }));
+			row.appendChild(element("td", project.tracks.join(", ")));
+		});
+		main.appendChild(table);
+	});
+}
+
+function showProject(id) {
+	fetchJSON("/projects/" + id).then(function (project) {
+		var main = document.getElementById("main");
+		main.replaceChildren(element("h1", project.path), element("p", [project.application, project.version].filter(Boolean).join(" ")));
+		var table = element("table");
+		table.appendChild(element("tr")).append(element("th", "Track"), element("th", "Plugins"));
+		Object.keys(project.trackPlugins).sort(function (a, b) { return a.localeCompare(b, undefined, { sensitivity: "base" }); }).forEach(function (track) {
+			var row = table.appendChild(element("tr"));
+			row.appendChild(element("td", track));
+			var cell = row.appendChild(element("td"));
+			project.trackPlugins[track].forEach(function (plugin, index) {
+				if (index > 0) { cell.append(", "); }
+				cell.appendChild(link(plugin, function () { showPlugin(plugin); }));
+			});
+		});
+		main.appendChild(table);
+		project.warnings.forEach(function (message) { main.appendChild(element("p", message)); });
+		project.errorMessages.forEach(function (message) { main.appendChild(element("p", message)).className = "error"; });
+	});
+}
+
+fetchJSON("/plugins").then(function (plugins) {
+	var list = document.getElementById("plugins");
+	plugins.forEach(function (plugin) {
+		var item = list.appendChild(element("li"));
+		item.appendChild(link(plugin.name, function () { showPlugin(plugin.name); }));
+		item.append(" (" + plugin.projects + ")");
+	});
+	document.getElementById("filter").addEventListener("input", function (event) {
+		var text = event.target.value.toLowerCase();
+		Array.prototype.forEach.call(list.children, function (item) {
+			item.hidden = item.textContent.toLowerCase().indexOf(text) < 0;
+		});
+	});
+});
+</script>
+</body>
+</html>
+`))
+
+// Run the serve command, which examines the projects within some folders, keeps the results up to date, and answers questions about them over HTTP.
+func runServe(args []string) int {
+	fs := newCommandFlagSet("serve", "[flags] <file|folder> [<file|folder> ...]")
+	var options scanOptions
+	options.register(fs)
+	var addressFlag = fs.String("address", defaultServeAddress, "The address on which to listen. Use \":8080\" to accept connections from other machines.")
+	var refreshFlag = fs.Duration("refresh", defaultRefreshInterval, "How often to look for new, changed and deleted project files (0 to examine them only once).")
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return 2
+	}
+
+	// Listen before the first scan, which may take a long time, so that a port that is already in use is reported straight away.
+	// Connections made during the scan wait until it has finished.
+	listener, err := net.Listen("tcp", *addressFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer listener.Close()
+
+	server := newProjectServer(options, fs.Args())
+	server.refresh()
+	fmt.Fprintf(os.Stderr, "Serving %d project(s) at http://%s/\n", len(server.projects), listener.Addr())
+
+	// Progress isn't shown for later refreshes, which happen in the background.
+	server.options.stats = false
+	if *refreshFlag > 0 {
+		go func() {
+			for range time.Tick(*refreshFlag) {
+				server.refresh()
+			}
+		}()
+	}
+
+	httpServer := &http.Server{
+		Handler:           server.handler(),
+		ReadHeaderTimeout: serveReadHeaderTimeout,
+		ReadTimeout:       serveReadTimeout,
+		WriteTimeout:      serveWriteTimeout,
+		IdleTimeout:       serveIdleTimeout,
+	}
+	if err := httpServer.Serve(listener); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
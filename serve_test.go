@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Get a URL from a test server and decode its JSON response into [value]. Returns the status code.
+func getTestJSON(t *testing.T, server *httptest.Server, path string, value any) int {
+	t.Helper()
+	response, err := http.Get(server.URL + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	if err := json.NewDecoder(response.Body).Decode(value); err != nil {
+		t.Fatalf("Can't decode the response to %s: %v", path, err)
+	}
+	return response.StatusCode
+}
+
+func TestServe(t *testing.T) {
+	folder := t.TempDir()
+	mixPath := writeTestFile(t, filepath.Join(folder, "Mix.rpp"), []byte(testRPP))
+	demoPath := writeTestFile(t, filepath.Join(folder, "Demo.rpp"), []byte(strings.Replace(testRPP, "Pro-L 2", "Pro-C 2", 2)))
+	notesPath := writeTestFile(t, filepath.Join(folder, "Notes.xml"), []byte("<notes/>"))
+
+	ps := newProjectServer(scanOptions{numThreads: 1}, []string{folder})
+	if examined := ps.refresh(); examined != 3 {
+		t.Fatalf("Expected 3 files to be examined, got %d", examined)
+	}
+	if _, ignored := ps.ignored[notesPath]; !ignored || len(ps.projects) != 2 {
+		t.Fatalf("Expected 2 projects and the XML file to be ignored, got %d projects and %v", len(ps.projects), ps.ignored)
+	}
+	// Unchanged files aren't examined again, including those that aren't supported projects.
+	if examined := ps.refresh(); examined != 0 {
+		t.Errorf("Expected unchanged files not to be examined again, got %d", examined)
+	}
+	server := httptest.NewServer(ps.handler())
+	defer server.Close()
+
+	var projects []projectSummaryJSON
+	if status := getTestJSON(t, server, "/projects", &projects); status != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", status)
+	}
+	if len(projects) != 2 || projects[0].Path != demoPath || projects[1].Path != mixPath {
+		t.Fatalf("Expected Demo.rpp and Mix.rpp, got %+v", projects)
+	}
+	if projects[1].Application != "REAPER" || projects[1].Plugins != 5 || projects[1].Tracks != 3 {
+		t.Errorf("Unexpected summary %+v", projects[1])
+	}
+
+	var project projectJSON
+	if status := getTestJSON(t, server, "/projects/"+projects[1].ID, &project); status != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", status)
+	}
+	if expected := []string{"loser/3BandEQ", "Pro-Q 3", "Surge XT"}; !reflect.DeepEqual(project.TrackPlugins["Lead Vox"], expected) {
+		t.Errorf("Expected %v on Lead Vox, got %v", expected, project.TrackPlugins["Lead Vox"])
+	}
+
+	var plugins []pluginSummaryJSON
+	getTestJSON(t, server, "/plugins", &plugins)
+	if len(plugins) != 6 || plugins[0].Name != "AUDelay" || plugins[0].Projects != 2 || plugins[0].Tracks != 2 {
+		t.Errorf("Unexpected plugins %+v", plugins)
+	}
+
+	// Plugin names can contain slashes, which are escaped in the URL.
+	var users []pluginProjectJSON
+	if status := getTestJSON(t, server, "/plugins/"+url.PathEscape("loser/3BandEQ")+"/projects", &users); status != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", status)
+	}
+	if len(users) != 2 || !reflect.DeepEqual(users[0].Tracks, []string{"Lead Vox"}) {
+		t.Errorf("Unexpected projects %+v", users)
+	}
+	getTestJSON(t, server, "/plugins/Pro-L%202/projects", &users)
+	if len(users) != 1 || users[0].Path != mixPath {
+		t.Errorf("Expected only Mix.rpp to use Pro-L 2, got %+v", users)
+	}
+
+	var failure map[string]string
+	if status := getTestJSON(t, server, "/projects/missing", &failure); status != http.StatusNotFound || len(failure["error"]) == 0 {
+		t.Errorf("Expected a 404 with an error, got %d %v", status, failure)
+	}
+	if status := getTestJSON(t, server, "/plugins/Missing/projects", &failure); status != http.StatusNotFound {
+		t.Errorf("Expected a 404, got %d", status)
+	}
+
+	// Changed projects are examined again, and deleted ones are forgotten.
+	writeTestFile(t, mixPath, []byte(strings.Replace(testRPP, "Pro-L 2", "Pro-MB", 2)))
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(mixPath, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(demoPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(notesPath); err != nil {
+		t.Fatal(err)
+	}
+	if examined := ps.refresh(); examined != 1 {
+		t.Errorf("Expected the changed project to be examined, got %d", examined)
+	}
+	getTestJSON(t, server, "/projects", &projects)
+	if len(projects) != 1 || projects[0].Path != mixPath || len(ps.ignored) != 0 {
+		t.Fatalf("Expected only Mix.rpp, got %+v and ignored files %v", projects, ps.ignored)
+	}
+	getTestJSON(t, server, "/plugins/Pro-MB/projects", &users)
+	if len(users) != 1 {
+		t.Errorf("Expected the changed project to use Pro-MB, got %+v", users)
+	}
+
+	response, err := http.Get(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK || !strings.HasPrefix(response.Header.Get("Content-Type"), "text/html") {
+		t.Errorf("Expected an HTML page, got %d %s", response.StatusCode, response.Header.Get("Content-Type"))
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/MrSplidge/go-xmldom"
+)
+
+const songExtension = ".song"
+
+// The parser for Studio One songs, which are zip archives that contain a metainfo.xml document and a Song/song.xml document.
+type songParser struct{}
+
+var PF_Song = registerProjectFormat(songParser{})
+
+func (songParser) Name() string { return "Studio One" }
+
+func (songParser) Extensions() []string { return []string{songExtension} }
+
+func (songParser) Sniff(header []byte, content io.ReaderAt, size int64) bool {
+	if !bytes.HasPrefix(header, []byte("PK\x03\x04")) {
+		return false
+	}
+	archive, err := zip.NewReader(content, size)
+	if err != nil {
+		return false
+	}
+	found := 0
+	for _, entry := range archive.File {
+		if entry.Name == songMetaInfoEntry || entry.Name == songSongEntry {
+			found++
+		}
+	}
+	return found == 2
+}
+
+func (songParser) Examine(path string) *projectInformation {
+	return examineSong(path)
+}
+
+// The archive entries of a Studio One song that identify it and hold its version.
+const (
+	songMetaInfoEntry = "metainfo.xml"
+	songSongEntry     = "Song/song.xml"
+)
+
+// The folder within a Studio One song that holds the documents describing the mixer and instruments.
+const songDevicesFolder = "Devices/"
+
+// Return the value of a Studio One attribute in the x namespace, such as x:id. The namespace may or may not be declared, so only the local part of the name is compared.
+func songNamespacedAttribute(node *xmldom.Node, local string) string {
+	for _, attribute := range node.Attributes {
+		if strings.HasSuffix(attribute.Name, ":"+local) {
+			return attribute.Value
+		}
+	}
+	return ""
+}
+
+// Given a plugin DOM node in a Studio One device document, find the name of the channel or instrument within which it appears.
+// Mixer channels are elements whose names end with Channel, and are named by their label. Other plugins, such as instruments, are named after their nearest named ancestor.
+func findSongTrackNameForNode(node *xmldom.Node) string {
+	for ancestor := node.Parent; ancestor != nil; ancestor = ancestor.Parent {
+		if strings.HasSuffix(ancestor.Name, "Channel") {
+			if label := ancestor.GetAttributeValue("label"); len(label) != 0 {
+				return label
+			}
+			return ancestor.GetAttributeValue("name")
+		}
+	}
+	for ancestor := node.Parent; ancestor != nil; ancestor = ancestor.Parent {
+		if name := ancestor.GetAttributeValue("name"); len(name) != 0 && songNamespacedAttribute(ancestor, "id") != "Inserts" {
+			return name
+		}
+	}
+	return ""
+}
+
+// Parse an XML document within a Studio One song. Errors are logged against the project, and nil is returned.
+func parseSongEntry(info *projectInformation, file *zip.File) *xmldom.Document {
+	reader, err := file.Open()
+	if err != nil {
+		info.logError(newProjectError(EK_IO, fmt.Errorf("%s: %w", file.Name, err)))
+		return nil
+	}
+	defer reader.Close()
+
+	dom, err := xmldom.Parse(reader)
+	if err != nil {
+		var syntaxError *xml.SyntaxError
+		if errors.As(err, &syntaxError) {
+			info.logError(newProjectError(EK_MalformedXML, fmt.Errorf("%s: %w", file.Name, err)))
+		} else {
+			info.logError(newProjectError(EK_IO, fmt.Errorf("%s: %w", file.Name, err)))
+		}
+		return nil
+	}
+	if dom.Root == nil {
+		info.logError(newProjectError(EK_MalformedXML, fmt.Errorf("%s: the document is empty", file.Name)))
+		return nil
+	}
+	return dom
+}
+
+// Extract the first tempo and time signature of a Studio One song from the tempo and time signature maps in its song document.
+func findSongMetadata(root *xmldom.Node, metadata *projectMetadata) {
+	if segment := root.QueryOne("//TempoMapSegment"); segment != nil {
+		metadata.tempo, _ = strconv.ParseFloat(segment.GetAttributeValue("tempo"), 64)
+	}
+	if segment := root.QueryOne("//TimeSignatureMapSegment"); segment != nil {
+		metadata.numerator, _ = strconv.Atoi(segment.GetAttributeValue("numerator"))
+		metadata.denominator, _ = strconv.Atoi(segment.GetAttributeValue("denominator"))
+	}
+}
+
+// Examine the contents of a Studio One song, which is a zip archive of XML documents, to obtain version information and a mapping of channel names to plugin names.
+// Insert effects are listed against the labels of their mixer channels, and instruments against their names.
+func examineSong(projectPath string) *projectInformation {
+	info := newProjectInformation(projectPath)
+
+	reader, size, err := openProjectFile(projectPath)
+	if err != nil {
+		info.logError(newProjectError(EK_IO, err))
+		return &info
+	}
+	defer reader.Close()
+
+	archive, err := zip.NewReader(reader, size)
+	if err != nil {
+		if errors.Is(err, zip.ErrFormat) {
+			info.logError(newProjectErrorAt(EK_NotZip, "", 0, err))
+		} else {
+			info.logError(newProjectError(EK_IO, err))
+		}
+		return &info
+	}
+
+	for _, file := range archive.File {
+		switch {
+		case file.Name == songMetaInfoEntry:
+			// The generator is recorded as the application name and version separated by a slash, such as "Studio One/6.5.2.97440".
+			if dom := parseSongEntry(&info, file); dom != nil {
+				if generator := dom.Root.QueryOne("//Attribute[@id='Document:Generator']"); generator != nil {
+					application, version, found := strings.Cut(generator.GetAttributeValue("value"), "/")
+					info.application = application
+					if found {
+						info.version = version
+					}
+				}
+				if sampleRate := dom.Root.QueryOne("//Attribute[@id='Media:SampleRate']"); sampleRate != nil {
+					info.metadata.sampleRate, _ = strconv.Atoi(sampleRate.GetAttributeValue("value"))
+				}
+			}
+		case file.Name == songSongEntry:
+			if dom := parseSongEntry(&info, file); dom != nil {
+				findSongMetadata(dom.Root, &info.metadata)
+			}
+		case strings.HasPrefix(file.Name, songDevicesFolder) && path.Ext(file.Name) == ".xml":
+			if dom := parseSongEntry(&info, file); dom != nil {
+				for _, node := range dom.Root.Query("//*") {
+					if songNamespacedAttribute(node, "id") != "deviceData" {
+						continue
+					}
+					plugin := node.GetAttributeValue("name")
+					if track := findSongTrackNameForNode(node); len(track) != 0 && len(plugin) != 0 {
+						info.mapTrackToPlugin(plugin, track)
+						info.setPluginVendor(plugin, node.GetAttributeValue("vendor"))
+					}
+				}
+			}
+		}
+	}
+
+	return &info
+}
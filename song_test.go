@@ -0,0 +1,123 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testSongMetaInfo = `<?xml version="1.0" encoding="UTF-8"?>
+<MetaInformation>
+	<Attribute id="Document:Title" value="Single"/>
+	<Attribute id="Document:Generator" value="Studio One/6.5.2.97440"/>
+</MetaInformation>`
+
+const testSongMixer = `<?xml version="1.0" encoding="UTF-8"?>
+<AudioMixer xmlns:x="http://www.presonus.com/x">
+	<Attributes x:id="channels">
+		<ChannelGroup name="AudioTrack">
+			<AudioTrackChannel label="Vocals" uniqueID="{1}">
+				<Attributes x:id="Inserts" name="Inserts">
+					<Attributes x:id="{A}" name="Pro EQ2">
+						<Attributes x:id="deviceData" name="Pro EQ2" classID="{E1}"/>
+					</Attributes>
+					<Attributes x:id="{B}" name="Pro-Q 3">
+						<Attributes x:id="deviceData" name="Pro-Q 3" vendor="FabFilter" classID="{E2}"/>
+					</Attributes>
+				</Attributes>
+			</AudioTrackChannel>
+		</ChannelGroup>
+		<ChannelGroup name="AudioOutput">
+			<AudioOutputChannel label="Main" uniqueID="{2}">
+				<Attributes x:id="Inserts" name="Inserts">
+					<Attributes x:id="{C}" name="Limiter2">
+						<Attributes x:id="deviceData" name="Limiter2" classID="{E3}"/>
+					</Attributes>
+				</Attributes>
+			</AudioOutputChannel>
+		</ChannelGroup>
+	</Attributes>
+</AudioMixer>`
+
+// Instruments aren't within channels, and the undeclared x prefix is what Studio One writes in some documents.
+const testSongSynths = `<?xml version="1.0" encoding="UTF-8"?>
+<AudioSynthFolder>
+	<Attributes x:id="{D}" name="Bass Synth">
+		<Attributes x:id="deviceData" name="Mai Tai" classID="{E4}"/>
+	</Attributes>
+</AudioSynthFolder>`
+
+// Build a zip archive from pairs of entry names and contents.
+func zipArchive(t *testing.T, entries ...string) []byte {
+	var b bytes.Buffer
+	writer := zip.NewWriter(&b)
+	for index := 0; index+1 < len(entries); index += 2 {
+		entry, err := writer.Create(entries[index])
+		if err != nil {
+			t.Fatal(err)
+		}
+		entry.Write([]byte(entries[index+1]))
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func TestExamineSong(t *testing.T) {
+	content := zipArchive(t,
+		songMetaInfoEntry, testSongMetaInfo,
+		songSongEntry, `<?xml version="1.0" encoding="UTF-8"?><Song/>`,
+		"Devices/audiomixer.xml", testSongMixer,
+		"Devices/audiosynthfolder.xml", testSongSynths)
+	path := writeTestFile(t, filepath.Join(t.TempDir(), "Single.song"), content)
+
+	if format := chooseProjectFormat(path); format != PF_Song {
+		t.Fatalf("Expected a Studio One song, got %s", format)
+	}
+
+	pi := examineProject(path)
+	if pi.failed() {
+		t.Fatalf("Unexpected errors: %v", pi.errors)
+	}
+	if pi.application != "Studio One" || pi.version != "6.5.2.97440" {
+		t.Errorf("Expected Studio One 6.5.2.97440, got %q %q", pi.application, pi.version)
+	}
+	expected := map[string][]string{
+		"Vocals":     {"Pro EQ2", "Pro-Q 3"},
+		"Main":       {"Limiter2"},
+		"Bass Synth": {"Mai Tai"},
+	}
+	if !reflect.DeepEqual(pi.trackToPluginMap, expected) {
+		t.Errorf("Expected %v, got %v", expected, pi.trackToPluginMap)
+	}
+	if vendor := pi.pluginVendors["Pro-Q 3"]; vendor != "FabFilter" {
+		t.Errorf("Expected vendor FabFilter, got %q", vendor)
+	}
+
+	// Other zip archives aren't recognised as songs.
+	if format := chooseProjectFormat(writeTestFile(t, filepath.Join(t.TempDir(), "Other.zip"), zipArchive(t, "readme.txt", "hello"))); format != PF_Unknown {
+		t.Errorf("Expected other zip archives not to be recognised, got %s", format)
+	}
+}
+
+func TestExamineSongErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	pi := examineSong(writeTestFile(t, filepath.Join(dir, "NotZip.song"), []byte("not a zip archive")))
+	if len(pi.errors) != 1 || pi.errors[0].kind != EK_NotZip {
+		t.Errorf("Expected a not zip error, got %v", pi.errors)
+	}
+
+	pi = examineSong(writeTestFile(t, filepath.Join(dir, "Malformed.song"), zipArchive(t,
+		songMetaInfoEntry, testSongMetaInfo,
+		"Devices/audiomixer.xml", "<AudioMixer><unclosed></AudioMixer>")))
+	if len(pi.errors) != 1 || pi.errors[0].kind != EK_MalformedXML {
+		t.Errorf("Expected a malformed XML error, got %v", pi.errors)
+	}
+	if pi.version != "6.5.2.97440" {
+		t.Errorf("Expected the version to be read despite the malformed mixer, got %q", pi.version)
+	}
+}
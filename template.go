@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// The name of the template, within a template file, that is executed once with the summary of the library after all projects have been examined.
+const libraryTemplateName = "library"
+
+// The functions that templates can call, in addition to the built-in ones such as len, index and printf.
+var templateFunctions = template.FuncMap{
+	"join":   strings.Join,
+	"sort":   sortedCI,
+	"unique": sortedCIUnique,
+	"lower":  strings.ToLower,
+	"upper":  strings.ToUpper,
+	"pad": func(width int, text string) string {
+		return padStringToWidth(text, width, ' ')
+	},
+}
+
+// Parse a user-defined output template from a file. The template is executed with each project, and the template named "library", if the file defines one, with the summary of the library.
+func parseOutputTemplate(path string) (*template.Template, error) {
+	t, err := template.New(filepath.Base(path)).Funcs(templateFunctions).ParseFiles(path)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse the template: %w", err)
+	}
+	return t, nil
+}
+
+// The accessors below expose a project to templates, which can only use exported methods. Lists are sorted case-insensitively.
+
+// Return the path of the project file.
+func (pi *projectInformation) Path() string { return pi.path }
+
+// Return the name of the project's format, such as ALS or CPR.
+func (pi *projectInformation) Format() string { return pi.format.String() }
+
+// Return the path of the project that this file is a backup of, or an empty string.
+func (pi *projectInformation) BackupOf() string { return pi.backupOf }
+
+// Return the name of the application that wrote the project, or an empty string if it isn't known.
+func (pi *projectInformation) Application() string { return pi.application }
+
+// Return the version of the project.
+func (pi *projectInformation) Version() string { return pi.version }
+
+// Return the description of the application that created the project, as recorded by Live, or an empty string.
+func (pi *projectInformation) Creator() string { return pi.metadata.creator }
+
+// Return the tempo at the start of the project in beats per minute, or zero if it isn't known.
+func (pi *projectInformation) Tempo() float64 { return pi.metadata.tempo }
+
+// Return the time signature at the start of the project, such as "4/4", or an empty string if it isn't known.
+func (pi *projectInformation) TimeSignature() string { return pi.metadata.timeSignature() }
+
+// Return the length of the arrangement, such as "3:25", or an empty string if it isn't known.
+func (pi *projectInformation) Length() string {
+	if pi.metadata.length <= 0 {
+		return ""
+	}
+	return formatLength(pi.metadata.length)
+}
+
+// Return the sample rate of the project in Hz, or zero if it isn't known.
+func (pi *projectInformation) SampleRate() int { return pi.metadata.sampleRate }
+
+// Return the bit depth at which audio is recorded, or zero if it isn't known.
+func (pi *projectInformation) BitDepth() int { return pi.metadata.bitDepth }
+
+// Return the names of the project's tracks.
+func (pi *projectInformation) Tracks() []string { return sortedCIKeys(pi.trackToPluginMap) }
+
+// Return the names of the plugins that the project uses.
+func (pi *projectInformation) Plugins() []string { return sortedCIKeys(pi.pluginToTrackMap) }
+
+// Return the names of the plugins used on a track.
+func (pi *projectInformation) PluginsOnTrack(track string) []string {
+	return sortedCIUnique(pi.trackToPluginMap[track])
+}
+
+// Return the names of the tracks on which a plugin is used.
+func (pi *projectInformation) TracksForPlugin(plugin string) []string {
+	return sortedCIUnique(pi.pluginToTrackMap[plugin])
+}
+
+// Return the vendor of a plugin, or an empty string if it isn't known.
+func (pi *projectInformation) Vendor(plugin string) string { return pi.pluginVendors[plugin] }
+
+// Return the paths of the media files that the project refers to that couldn't be found.
+func (pi *projectInformation) MissingMedia() []string {
+	paths := []string{}
+	for _, file := range pi.sortedMediaFiles() {
+		if file.missing {
+			paths = append(paths, file.path)
+		}
+	}
+	return paths
+}
+
+// Return descriptions of the errors encountered while examining the project.
+func (pi *projectInformation) Errors() []string {
+	errors := []string{}
+	for _, err := range pi.errors {
+		errors = append(errors, err.Error())
+	}
+	return errors
+}
+
+// Return the warnings raised while examining the project.
+func (pi *projectInformation) Warnings() []string {
+	return append([]string{}, pi.warnings...)
+}
+
+// Check whether any errors were encountered while examining the project.
+func (pi *projectInformation) Failed() bool { return pi.failed() }
+
+// The accessors below expose the summary of a library to templates.
+
+// Return the number of projects in the library.
+func (li *libraryInformation) ProjectCount() int { return li.projectCount }
+
+// Return the names of the plugins used across the library.
+func (li *libraryInformation) Plugins() []string { return sortedCIKeys(li.pluginToProjectMap) }
+
+// Return the paths of the projects that use a plugin.
+func (li *libraryInformation) ProjectsForPlugin(plugin string) []string {
+	return sortedCIUnique(li.pluginToProjectMap[plugin])
+}
+
+// Return the number of tracks across the library that use a plugin.
+func (li *libraryInformation) TrackCount(plugin string) int { return li.pluginTrackCounts[plugin] }
+
+// Return the keys of a map, sorted case-insensitively.
+func sortedCIKeys(m map[string][]string) []string {
+	keys := []string{}
+	iterateOverCISortedMap(m, func(key string, _ []string) {
+		keys = append(keys, key)
+	})
+	return keys
+}
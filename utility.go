@@ -29,6 +29,7 @@ func padStringToWidth(text string, width int, padRune rune) string {
 }
 
 // Scans the file and/or folder paths passed in [paths], applies filters to the files and folders found therein, and returns a slice containing the fully-qualified paths.
+// Archives are descended into, and the files within them are returned as paths of the form "Archive.zip!/Set/Set.als".
 func scanAndFilterPaths(paths []string, includeFolder func(basename, fullPath string) bool, includeFile func(basename, fullPath string) bool) []string {
 	projectPaths := []string{}
 	stack := append([]string{}, paths...)
@@ -37,14 +38,26 @@ func scanAndFilterPaths(paths []string, includeFolder func(basename, fullPath st
 		inputPath := stack[len(stack)-1]
 		stack = stack[:len(stack)-1]
 
+		// Entries within archives can be named directly.
+		if _, _, isEntry := splitArchivePath(inputPath); isEntry {
+			if _, err := projectFileSize(inputPath); err != nil {
+				os.Stderr.WriteString(fmt.Errorf("can't stat %s: %w", inputPath, err).Error() + "\n")
+			} else if includeFile(filepath.Base(inputPath), inputPath) {
+				projectPaths = append(projectPaths, inputPath)
+			}
+			continue
+		}
+
 		fileInfo, err := os.Stat(inputPath)
 		if err != nil {
-			os.Stderr.WriteString(fmt.Errorf("can't stat %s: %w", inputPath, err).Error())
+			os.Stderr.WriteString(fmt.Errorf("can't stat %s: %w", inputPath, err).Error() + "\n")
 			continue
 		}
 
 		if !fileInfo.IsDir() {
-			if includeFile(filepath.Base(inputPath), inputPath) {
+			if isArchive(inputPath) {
+				projectPaths = append(projectPaths, listArchiveOrWarn(inputPath, includeFolder, includeFile)...)
+			} else if includeFile(filepath.Base(inputPath), inputPath) {
 				projectPaths = append(projectPaths, inputPath)
 			}
 			continue
@@ -63,6 +76,8 @@ func scanAndFilterPaths(paths []string, includeFolder func(basename, fullPath st
 				if includeFolder(entryName, fullPath) {
 					stack = append(stack, fullPath)
 				}
+			} else if isArchive(entryName) {
+				projectPaths = append(projectPaths, listArchiveOrWarn(fullPath, includeFolder, includeFile)...)
 			} else if includeFile(entryName, fullPath) {
 				projectPaths = append(projectPaths, fullPath)
 			}